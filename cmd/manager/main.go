@@ -82,6 +82,9 @@ func main() {
 	}))
 	controller.NewProviderIDController(mgr.GetClient(), oVirtClientService.NewCachedClient("providerID")).AddToManager(mgr)
 	controller.NewNodeController(mgr.GetClient(), oVirtClientService.NewCachedClient("node")).AddToManager(mgr)
+	controller.NewOrphanController(mgr.GetClient(), oVirtClientService.NewCachedClient("orphan"),
+		mgr.GetEventRecorderFor("ovirt-orphan-controller"), flags.Namespace,
+		flags.OrphanCheckInterval, flags.OrphanDeletionGracePeriod).AddToManager(mgr)
 
 	// start the service to receive secret updates and immediately return
 	oVirtClientService.Run(ctx)
@@ -121,6 +124,9 @@ type Flags struct {
 	LeaderElectResourceNamespace string
 	LeaderElect                  bool
 	LeaderElectLeaseDuration     time.Duration
+
+	OrphanCheckInterval       time.Duration
+	OrphanDeletionGracePeriod time.Duration
 }
 
 func (f Flags) ToManagerOptions() manager.Options {
@@ -184,6 +190,18 @@ func parseFlags() Flags {
 		"The duration that non-leader candidates will wait after observing a leadership renewal until attempting to acquire leadership of a led but unrenewed leader slot. This is effectively the maximum duration that a leader can be stopped before it is replaced by another candidate. This is only applicable if leader election is enabled.",
 	)
 
+	orphanCheckInterval := flag.Duration(
+		"orphan-vm-check-interval",
+		10*time.Minute,
+		"The interval the VMs tagged with the infrastructure ID of the cluster are checked for VMs which don't belong to any machine.",
+	)
+
+	orphanDeletionGracePeriod := flag.Duration(
+		"orphan-vm-deletion-grace-period",
+		0,
+		"The time a VM has to be orphaned before it is removed. If 0, orphaned VMs are only reported and never removed.",
+	)
+
 	flag.Parse()
 
 	return Flags{
@@ -193,6 +211,8 @@ func parseFlags() Flags {
 		LeaderElectResourceNamespace: *leaderElectResourceNamespace,
 		LeaderElect:                  *leaderElect,
 		LeaderElectLeaseDuration:     *leaderElectLeaseDuration,
+		OrphanCheckInterval:          *orphanCheckInterval,
+		OrphanDeletionGracePeriod:    *orphanDeletionGracePeriod,
 	}
 }
 
@@ -69,11 +69,21 @@ func (actuator *OvirtActuator) Create(ctx context.Context, machine *machinev1.Ma
 	}
 
 	if err := validateMachine(ovirtClient, providerSpec); err != nil {
+		if isEngineError(err) {
+			// the spec may be valid, the machine is requeued instead of failed
+			actuator.eventRecorder.Eventf(machine, corev1.EventTypeWarning, "Create",
+				"error validating machine fields: %v", err)
+			return errors.Wrap(err, "error validating machine fields")
+		}
 		return actuator.handleMachineError(machine, "Create", apierrors.InvalidMachineConfiguration(
 			"error validating machine fields: %v", err))
 	}
 
-	mScope := newMachineScope(ctx, ovirtClient, actuator.client, machine, providerSpec)
+	mScope := newMachineScope(ctx, ovirtClient, actuator.client, actuator.eventRecorder, machine, providerSpec)
+	if err := mScope.resolveNames(); err != nil {
+		return actuator.handleMachineError(machine, "Create", apierrors.CreateMachine(
+			"error resolving names of machine fields: %v", err))
+	}
 	if err := mScope.create(); err != nil {
 		return actuator.handleMachineError(machine, "Create", apierrors.CreateMachine(
 			"error creating Machine %v", err))
@@ -106,9 +116,44 @@ func (actuator *OvirtActuator) Update(ctx context.Context, machine *machinev1.Ma
 			"failed to create connection to oVirt API %v", err))
 	}
 
-	mScope := newMachineScope(ctx, ovirtClient, actuator.client, machine, providerSpec)
+	mScope := newMachineScope(ctx, ovirtClient, actuator.client, actuator.eventRecorder, machine, providerSpec)
+	if err := mScope.resolveNames(); err != nil {
+		return actuator.handleMachineError(machine, "Update", apierrors.UpdateMachine(
+			"error resolving names of machine fields: %v", err))
+	}
+
+	recreating, err := mScope.reconcileRecreation()
+	if recreating {
+		if patchErr := mScope.patchMachine(ctx); patchErr != nil {
+			actuator.logger.Errorf("failed to record recreation of machine %s: %v", machine.Name, patchErr)
+		}
+		if err != nil {
+			return actuator.handleMachineError(machine, "Update", apierrors.UpdateMachine(
+				"error recreating Machine %v", err))
+		}
+		actuator.eventRecorder.Eventf(machine, corev1.EventTypeNormal, "Recreating",
+			"Recreating the VM of Machine %v after its creation failed", machine.Name)
+		return nil
+	}
+	if err != nil {
+		return actuator.handleMachineError(machine, "Update", apierrors.UpdateMachine(
+			"error recreating Machine %v", err))
+	}
+
+	if err := mScope.reconcileCreation(); err != nil {
+		// keep the progress of the creation phases
+		if patchErr := mScope.patchMachine(ctx); patchErr != nil {
+			actuator.logger.Errorf("failed to record creation phase of machine %s: %v", machine.Name, patchErr)
+		}
+		return actuator.handleMachineError(machine, "Update", apierrors.UpdateMachine(
+			"error creating Machine %v", err))
+	}
 
 	if err := mScope.reconcileMachine(ctx); err != nil {
+		// keep the conditions explaining why the machine is not reconciled
+		if patchErr := mScope.patchMachine(ctx); patchErr != nil {
+			actuator.logger.Errorf("failed to record provider status of machine %s: %v", machine.Name, patchErr)
+		}
 		return actuator.handleMachineError(machine, "Update", apierrors.UpdateMachine(
 			"error reconciling Machine %v", err))
 	}
@@ -132,7 +177,7 @@ func (actuator *OvirtActuator) Exists(ctx context.Context, machine *machinev1.Ma
 		return false, actuator.handleMachineError(machine, "Exists", apierrors.InvalidMachineConfiguration(
 			"failed to create connection to oVirt API: %v", err))
 	}
-	mScope := newMachineScope(ctx, ovirtClient, actuator.client, machine, nil)
+	mScope := newMachineScope(ctx, ovirtClient, actuator.client, actuator.eventRecorder, machine, nil)
 
 	return mScope.exists()
 }
@@ -147,8 +192,33 @@ func (actuator *OvirtActuator) Delete(ctx context.Context, machine *machinev1.Ma
 			"failed to create connection to oVirt API: %v", err))
 	}
 
-	mScope := newMachineScope(ctx, ovirtClient, actuator.client, machine, nil)
-	if err := mScope.delete(); err != nil {
+	// the provider spec is only needed for the shutdown policy, a machine with an invalid spec is still deleted
+	providerSpec, err := ovirtconfigv1.ProviderSpecFromRawExtension(machine.Spec.ProviderSpec.Value)
+	if err != nil {
+		actuator.logger.Errorf("cannot unmarshal machineProviderSpec field of machine %s, "+
+			"using the default shutdown policy: %v", machine.Name, err)
+		providerSpec = nil
+	}
+
+	mScope := newMachineScope(ctx, ovirtClient, actuator.client, actuator.eventRecorder, machine, providerSpec)
+	preservedDisks, err := mScope.delete()
+	for _, disk := range preservedDisks {
+		actuator.eventRecorder.Eventf(machine, corev1.EventTypeNormal, "DiskPreserved",
+			"Detached disk %s from the VM of Machine %v before removing it", disk, machine.Name)
+	}
+	if err != nil {
+		var requeueAfterError *apierrors.RequeueAfterError
+		if errors.As(err, &requeueAfterError) {
+			actuator.logger.Infof("waiting for VM of machine %s to stop", machine.Name)
+			return err
+		}
+		var notOwnedError *vmNotOwnedError
+		if errors.As(err, &notOwnedError) {
+			return actuator.handleMachineError(machine, "Delete", &apierrors.MachineError{
+				Reason:  VMNotOwnedMachineError,
+				Message: notOwnedError.Error(),
+			})
+		}
 		return actuator.handleMachineError(machine, "Deleted", apierrors.UpdateMachine(
 			"error deleting oVirt instance %v", err))
 	}
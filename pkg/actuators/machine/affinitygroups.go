@@ -0,0 +1,267 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	affinityPositive = "positive"
+	affinityNegative = "negative"
+
+	affinityRuleVMs   = "vms"
+	affinityRuleHosts = "hosts"
+
+	spreadPolicySoft = "soft"
+	spreadPolicyHard = "hard"
+
+	spreadAffinityGroupSuffix = "-spread"
+
+	// AffinityGroupPolicyMismatchReason is the reason of the event emitted when an existing affinity group
+	// has another policy than the declared one.
+	AffinityGroupPolicyMismatchReason = "AffinityGroupPolicyMismatch"
+)
+
+// reconcileAffinityGroups adds the VM to the declared affinity groups and to the spread affinity group of its
+// MachineSet. Missing groups are created with the declared policy, existing groups are used as they are and
+// a warning is emitted if their policy differs. The declared hosts missing from a group are added to it.
+func (ms *machineScope) reconcileAffinityGroups(vm ovirtC.VM) error {
+	clusterID := ovirtC.ClusterID(ms.machineProviderSpec.ClusterId)
+	declaredGroups := ms.machineProviderSpec.AffinityGroups
+	spreadGroup := ms.spreadAffinityGroup()
+	if spreadGroup != nil {
+		declaredGroups = append(declaredGroups[:len(declaredGroups):len(declaredGroups)], spreadGroup)
+	}
+	for _, declared := range declaredGroups {
+		if declared == nil {
+			continue
+		}
+		ag, err := ms.ovirtClient.GetAffinityGroupByName(clusterID, declared.Name, ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			if !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+				return errors.Wrapf(err, "failed to get affinity group %s", declared.Name)
+			}
+			ms.logger.Infof("creating affinity group %s in cluster %s", declared.Name, clusterID)
+			params := createAffinityGroupParams(declared)
+			if declared == spreadGroup {
+				// the description marks the group as managed, so it is removed once it is empty
+				params = params.MustWithDescription(ms.spreadAffinityGroupDescription())
+			}
+			ag, err = ms.ovirtClient.CreateAffinityGroup(clusterID, declared.Name, params,
+				ovirtC.ContextStrategy(ms.Context))
+			if err != nil {
+				// another machine may have created the group meanwhile, the phase is retried then
+				return errors.Wrapf(err, "failed to create affinity group %s", declared.Name)
+			}
+		} else if differences := affinityGroupPolicyDifferences(ag, declared); len(differences) > 0 {
+			ms.logger.Warningf("affinity group %s doesn't have the declared policy: %s",
+				declared.Name, strings.Join(differences, ", "))
+			ms.eventf(corev1.EventTypeWarning, AffinityGroupPolicyMismatchReason,
+				"Affinity group %s doesn't have the declared policy: %s", declared.Name, strings.Join(differences, ", "))
+		}
+		if err := ms.reconcileAffinityGroupHosts(clusterID, ag, declared.HostIDs); err != nil {
+			return err
+		}
+		if affinityGroupHasVM(ag, vm.ID()) {
+			// the tagging phase is resumed after a failure
+			continue
+		}
+		if err := ag.AddVM(vm.ID(), ovirtC.ContextStrategy(ms.Context)); err != nil {
+			return errors.Wrapf(err, "failed to add VM %s to affinity group %s", vm.ID(), declared.Name)
+		}
+	}
+	return nil
+}
+
+// reconcileAffinityGroupHosts adds the hosts which aren't in the affinity group yet to it.
+// go-ovirt-client doesn't support the hosts of affinity groups, so the oVirt SDK is used.
+func (ms *machineScope) reconcileAffinityGroupHosts(clusterID ovirtC.ClusterID, ag ovirtC.AffinityGroup,
+	hostIDs []string) error {
+	if len(hostIDs) == 0 {
+		return nil
+	}
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return err
+	}
+	hostsService := conn.SystemService().ClustersService().ClusterService(string(clusterID)).
+		AffinityGroupsService().GroupService(string(ag.ID())).HostsService()
+	response, err := hostsService.List().Send()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list hosts of affinity group %s", ag.Name())
+	}
+	groupHosts := map[string]bool{}
+	if hosts, ok := response.Hosts(); ok {
+		for _, host := range hosts.Slice() {
+			id, _ := host.Id()
+			groupHosts[id] = true
+		}
+	}
+	for _, hostID := range hostIDs {
+		if groupHosts[hostID] {
+			continue
+		}
+		ms.logger.Infof("adding host %s to affinity group %s", hostID, ag.Name())
+		host := ovirtsdk.NewHostBuilder().Id(hostID).MustBuild()
+		if _, err := hostsService.Add().Host(host).Send(); err != nil {
+			return errors.Wrapf(err, "failed to add host %s to affinity group %s", hostID, ag.Name())
+		}
+	}
+	return nil
+}
+
+// createAffinityGroupParams returns the parameters creating an affinity group with the declared policy.
+// The rule which isn't declared is disabled.
+func createAffinityGroupParams(declared *ovirtconfigv1.AffinityGroup) ovirtC.BuildableCreateAffinityGroupOptionalParams {
+	affinity := declaredAffinity(declared)
+	params := ovirtC.CreateAffinityGroupParams()
+	if declaredAffinityRule(declared) == affinityRuleHosts {
+		return params.
+			MustWithHostsRuleParameters(true, affinity, declared.Enforcing).
+			MustWithVMsRuleParameters(false, ovirtC.AffinityNegative, false)
+	}
+	return params.
+		MustWithVMsRuleParameters(true, affinity, declared.Enforcing).
+		MustWithHostsRuleParameters(false, ovirtC.AffinityNegative, false)
+}
+
+// affinityGroupPolicyDifferences returns the differences between the rule of an existing affinity group
+// and the declared rule.
+func affinityGroupPolicyDifferences(ag ovirtC.AffinityGroup, declared *ovirtconfigv1.AffinityGroup) []string {
+	ruleName := declaredAffinityRule(declared)
+	var rule ovirtC.AffinityRule = ag.VMsRule()
+	if ruleName == affinityRuleHosts {
+		rule = ag.HostsRule()
+	}
+	if rule == nil || !rule.Enabled() {
+		return []string{fmt.Sprintf("the %s rule is disabled", ruleName)}
+	}
+
+	var differences []string
+	if affinity := declaredAffinity(declared); rule.Affinity() != affinity {
+		differences = append(differences, fmt.Sprintf("the %s rule is %s instead of %s",
+			ruleName, affinityName(rule.Affinity()), affinityName(affinity)))
+	}
+	if rule.Enforcing() != declared.Enforcing {
+		differences = append(differences, fmt.Sprintf("the %s rule has enforcing %t instead of %t",
+			ruleName, rule.Enforcing(), declared.Enforcing))
+	}
+	return differences
+}
+
+// spreadAffinityGroup returns the negative VM affinity group spreading the VMs of the MachineSet owning the
+// machine over the hosts, or nil if the machine has no spread policy or isn't owned by a MachineSet.
+func (ms *machineScope) spreadAffinityGroup() *ovirtconfigv1.AffinityGroup {
+	policy := ms.machineProviderSpec.SpreadPolicy
+	name := ms.spreadAffinityGroupName()
+	if policy == "" || name == "" {
+		return nil
+	}
+	return &ovirtconfigv1.AffinityGroup{
+		Name:      name,
+		Affinity:  affinityNegative,
+		Enforcing: policy == spreadPolicyHard,
+		Rule:      affinityRuleVMs,
+	}
+}
+
+// spreadAffinityGroupName returns the name of the spread affinity group of the MachineSet owning the machine,
+// or an empty string if the machine isn't owned by a MachineSet.
+func (ms *machineScope) spreadAffinityGroupName() string {
+	machineSet := ms.machineSetName()
+	if machineSet == "" {
+		return ""
+	}
+	return machineSet + spreadAffinityGroupSuffix
+}
+
+func (ms *machineScope) spreadAffinityGroupDescription() string {
+	return fmt.Sprintf("Spreads the VMs of MachineSet %s, managed by the oVirt machine actuator", ms.machineSetName())
+}
+
+// releaseSpreadAffinityGroup removes the VM from the spread affinity group of its MachineSet and removes the
+// group once no other VM is left in it. Groups with the name of the spread affinity group which weren't created
+// by the actuator are left alone.
+func (ms *machineScope) releaseSpreadAffinityGroup(vm ovirtC.VM) error {
+	name := ms.spreadAffinityGroupName()
+	if name == "" {
+		return nil
+	}
+	ag, err := ms.ovirtClient.GetAffinityGroupByName(vm.ClusterID(), name, ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		if ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+			return nil
+		}
+		return errors.Wrapf(err, "failed to get affinity group %s", name)
+	}
+	if ag.Description() != ms.spreadAffinityGroupDescription() {
+		return nil
+	}
+
+	otherVMs := 0
+	for _, id := range ag.VMIDs() {
+		if id != vm.ID() {
+			otherVMs++
+		}
+	}
+	if otherVMs > 0 {
+		if !affinityGroupHasVM(ag, vm.ID()) {
+			return nil
+		}
+		if err := ag.RemoveVM(vm.ID(), ovirtC.ContextStrategy(ms.Context)); err != nil {
+			return errors.Wrapf(err, "failed to remove VM %s from affinity group %s", vm.ID(), name)
+		}
+		return nil
+	}
+	// a machine adding its VM to the group meanwhile fails and creates the group again on the next reconcile
+	ms.logger.Infof("removing empty affinity group %s", name)
+	if err := ag.Remove(ovirtC.ContextStrategy(ms.Context)); err != nil && !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+		return errors.Wrapf(err, "failed to remove affinity group %s", name)
+	}
+	return nil
+}
+
+func affinityGroupHasVM(ag ovirtC.AffinityGroup, id ovirtC.VMID) bool {
+	for _, vmID := range ag.VMIDs() {
+		if vmID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func declaredAffinity(declared *ovirtconfigv1.AffinityGroup) ovirtC.Affinity {
+	if declared.Affinity == affinityPositive {
+		return ovirtC.AffinityPositive
+	}
+	return ovirtC.AffinityNegative
+}
+
+func declaredAffinityRule(declared *ovirtconfigv1.AffinityGroup) string {
+	if declared.Rule == affinityRuleHosts {
+		return affinityRuleHosts
+	}
+	return affinityRuleVMs
+}
+
+func affinityName(affinity ovirtC.Affinity) string {
+	if affinity == ovirtC.AffinityPositive {
+		return affinityPositive
+	}
+	return affinityNegative
+}
+
+// eventf records an event on the machine if the machine scope has an event recorder.
+func (ms *machineScope) eventf(eventType string, reason string, messageFmt string, args ...interface{}) {
+	if ms.eventRecorder == nil {
+		return
+	}
+	ms.eventRecorder.Eventf(ms.machine, eventType, reason, messageFmt, args...)
+}
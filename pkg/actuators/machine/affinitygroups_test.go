@@ -0,0 +1,193 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+)
+
+func TestMachineScope_ReconcileAffinityGroups(t *testing.T) {
+	testcases := []struct {
+		name            string
+		existingRule    ovirtclient.AffinityVMsRule
+		declared        *v1beta1.AffinityGroup
+		expectMismatch  bool
+		expectedEnabled bool
+		expectedVMsRule ovirtclient.Affinity
+	}{
+		{
+			name:            "missing group is created with the declared policy",
+			declared:        &v1beta1.AffinityGroup{Name: "spread", Affinity: "negative", Enforcing: true},
+			expectedEnabled: true,
+			expectedVMsRule: ovirtclient.AffinityNegative,
+		},
+		{
+			name:            "missing group with a hosts rule has a disabled VMs rule",
+			declared:        &v1beta1.AffinityGroup{Name: "hosts", Affinity: "positive", Rule: "hosts"},
+			expectedEnabled: false,
+			expectedVMsRule: ovirtclient.AffinityNegative,
+		},
+		{
+			name: "existing group with the declared policy is used",
+			existingRule: ovirtclient.CreateAffinityGroupParams().
+				MustWithVMsRuleParameters(true, ovirtclient.AffinityPositive, false).VMsRule(),
+			declared:        &v1beta1.AffinityGroup{Name: "together", Affinity: "positive"},
+			expectedEnabled: true,
+			expectedVMsRule: ovirtclient.AffinityPositive,
+		},
+		{
+			name: "existing group with another policy is kept with a warning",
+			existingRule: ovirtclient.CreateAffinityGroupParams().
+				MustWithVMsRuleParameters(true, ovirtclient.AffinityPositive, false).VMsRule(),
+			declared:        &v1beta1.AffinityGroup{Name: "together", Affinity: "negative"},
+			expectMismatch:  true,
+			expectedEnabled: true,
+			expectedVMsRule: ovirtclient.AffinityPositive,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+			if err != nil {
+				t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+			}
+			ovirtClient := helper.GetClient()
+			template, err := ovirtClient.GetBlankTemplate()
+			if err != nil {
+				t.Fatalf("Failed to get blank template: %v", err)
+			}
+			vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+			if err != nil {
+				t.Fatalf("Failed to create VM: %v", err)
+			}
+			if testcase.existingRule != nil {
+				if _, err := ovirtClient.CreateAffinityGroup(helper.GetClusterID(), testcase.declared.Name,
+					ovirtclient.CreateAffinityGroupParams().MustWithVMsRule(testcase.existingRule)); err != nil {
+					t.Fatalf("Failed to create affinity group: %v", err)
+				}
+			}
+
+			spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+			spec.AffinityGroups = []*v1beta1.AffinityGroup{testcase.declared}
+			recorder := record.NewFakeRecorder(10)
+			ms := machineScope{
+				Context:             context.Background(),
+				logger:              ovirt.NewKLogr("test"),
+				ovirtClient:         ovirtClient,
+				eventRecorder:       recorder,
+				machineProviderSpec: spec,
+				machine:             &machinev1.Machine{ObjectMeta: v1.ObjectMeta{Name: "test-machine"}},
+			}
+
+			if err := ms.reconcileAffinityGroups(vm); err != nil {
+				t.Fatalf("Unexpected error occurred while reconciling the affinity groups: %v", err)
+			}
+			ag, err := ovirtClient.GetAffinityGroupByName(helper.GetClusterID(), testcase.declared.Name)
+			if err != nil {
+				t.Fatalf("Failed to get affinity group: %v", err)
+			}
+			if !affinityGroupHasVM(ag, vm.ID()) {
+				t.Errorf("Expected VM to be added to affinity group %s", ag.Name())
+			}
+			if ag.VMsRule().Enabled() != testcase.expectedEnabled || ag.VMsRule().Affinity() != testcase.expectedVMsRule {
+				t.Errorf("Expected VMs rule enabled %t with affinity %t, but got enabled %t with affinity %t",
+					testcase.expectedEnabled, testcase.expectedVMsRule, ag.VMsRule().Enabled(), ag.VMsRule().Affinity())
+			}
+			if testcase.declared.Enforcing && !ag.VMsRule().Enforcing() {
+				t.Errorf("Expected VMs rule of affinity group %s to be enforcing", ag.Name())
+			}
+
+			mismatch := false
+			select {
+			case event := <-recorder.Events:
+				mismatch = strings.Contains(event, AffinityGroupPolicyMismatchReason)
+			default:
+			}
+			if mismatch != testcase.expectMismatch {
+				t.Errorf("Expected policy mismatch warning to be %t, but got %t", testcase.expectMismatch, mismatch)
+			}
+		})
+	}
+}
+
+func TestMachineScope_SpreadAffinityGroup(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+	spec.SpreadPolicy = spreadPolicyHard
+	isController := true
+
+	var scopes []*machineScope
+	var vms []ovirtclient.VM
+	for _, name := range []string{"workers-0", "workers-1"} {
+		vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), name, nil)
+		if err != nil {
+			t.Fatalf("Failed to create VM: %v", err)
+		}
+		ms := &machineScope{
+			Context:             context.Background(),
+			logger:              ovirt.NewKLogr("test"),
+			ovirtClient:         ovirtClient,
+			machineProviderSpec: spec,
+			machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+				Name: name,
+				OwnerReferences: []v1.OwnerReference{
+					{Kind: "MachineSet", Name: "workers", Controller: &isController},
+				},
+			}},
+		}
+		if err := ms.reconcileAffinityGroups(vm); err != nil {
+			t.Fatalf("Unexpected error occurred while reconciling the affinity groups: %v", err)
+		}
+		scopes = append(scopes, ms)
+		vms = append(vms, vm)
+	}
+
+	ag, err := ovirtClient.GetAffinityGroupByName(helper.GetClusterID(), "workers-spread")
+	if err != nil {
+		t.Fatalf("Failed to get spread affinity group: %v", err)
+	}
+	if len(ag.VMIDs()) != 2 {
+		t.Errorf("Expected both VMs in the spread affinity group, but got %v", ag.VMIDs())
+	}
+	if rule := ag.VMsRule(); !rule.Enabled() || rule.Affinity() != ovirtclient.AffinityNegative || !rule.Enforcing() {
+		t.Errorf("Expected an enforcing negative VMs rule, but got enabled %t, affinity %t, enforcing %t",
+			rule.Enabled(), rule.Affinity(), rule.Enforcing())
+	}
+
+	if err := scopes[0].releaseSpreadAffinityGroup(vms[0]); err != nil {
+		t.Fatalf("Unexpected error occurred while releasing the spread affinity group: %v", err)
+	}
+	ag, err = ovirtClient.GetAffinityGroupByName(helper.GetClusterID(), "workers-spread")
+	if err != nil {
+		t.Fatalf("Expected the spread affinity group to be kept while it has VMs, but got error: %v", err)
+	}
+	if len(ag.VMIDs()) != 1 || ag.VMIDs()[0] != vms[1].ID() {
+		t.Errorf("Expected only VM %s in the spread affinity group, but got %v", vms[1].ID(), ag.VMIDs())
+	}
+
+	if err := scopes[1].releaseSpreadAffinityGroup(vms[1]); err != nil {
+		t.Fatalf("Unexpected error occurred while releasing the spread affinity group: %v", err)
+	}
+	_, err = ovirtClient.GetAffinityGroupByName(helper.GetClusterID(), "workers-spread")
+	if err == nil || !ovirtclient.HasErrorCode(err, ovirtclient.ENotFound) {
+		t.Errorf("Expected the empty spread affinity group to be removed, but got error: %v", err)
+	}
+}
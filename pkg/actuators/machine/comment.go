@@ -0,0 +1,80 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const vmCommentPrefix = "Managed by the OpenShift machine API, delete the Machine instead of the VM."
+
+// vmComment returns the comment of the VM pointing engine admins to the Machine owning it. The comment is a fixed
+// sentence followed by space separated key=value pairs of the cluster infrastructure ID, the Machine, its UID,
+// the MachineSet owning it and its creation timestamp, e.g.
+// "... cluster=ocp-x7k2 machine=openshift-machine-api/ocp-x7k2-worker-0-abcde uid=... machineset=ocp-x7k2-worker-0
+// created=2022-01-01T00:00:00Z". Values which are unknown are left out.
+func (ms *machineScope) vmComment() string {
+	fields := []string{vmCommentPrefix}
+	if infraID := ms.machine.Labels[utils.ClusterIDLabel]; infraID != "" {
+		fields = append(fields, "cluster="+infraID)
+	}
+	fields = append(fields, fmt.Sprintf("machine=%s/%s", ms.machine.Namespace, ms.machine.Name))
+	if ms.machine.UID != "" {
+		fields = append(fields, fmt.Sprintf("uid=%s", ms.machine.UID))
+	}
+	if machineSet := ms.machineSetName(); machineSet != "" {
+		fields = append(fields, "machineset="+machineSet)
+	}
+	if !ms.machine.CreationTimestamp.IsZero() {
+		fields = append(fields, "created="+ms.machine.CreationTimestamp.UTC().Format(time.RFC3339))
+	}
+	return strings.Join(fields, " ")
+}
+
+// machineSetName returns the name of the MachineSet owning the machine, or an empty string if the machine isn't
+// owned by a MachineSet.
+func (ms *machineScope) machineSetName() string {
+	owner := metav1.GetControllerOf(ms.machine)
+	if owner == nil || owner.Kind != "MachineSet" {
+		return ""
+	}
+	return owner.Name
+}
+
+// managedVMComment returns the comment of the VM with the managed part replaced by the one of vmComment.
+// The managed part starts with vmCommentPrefix and runs to the end of the comment, the text engine admins wrote
+// before it is kept. A comment without a managed part is kept and the managed part is appended to it.
+func (ms *machineScope) managedVMComment(current string) string {
+	comment := ms.vmComment()
+	adminComment := current
+	if i := strings.Index(current, vmCommentPrefix); i >= 0 {
+		adminComment = current[:i]
+	}
+	adminComment = strings.TrimSpace(adminComment)
+	if adminComment == "" {
+		return comment
+	}
+	return adminComment + " " + comment
+}
+
+// reconcileVMComment updates the managed part of the comment of the VM if it doesn't point to the machine
+// anymore, e.g. because the VM was created before the comment was introduced or the machine was adopted by
+// another MachineSet. Comments of engine admins are kept.
+func (ms *machineScope) reconcileVMComment(vm ovirtC.VM) error {
+	comment := ms.managedVMComment(vm.Comment())
+	if vm.Comment() == comment {
+		return nil
+	}
+	_, err := ms.ovirtClient.UpdateVM(vm.ID(), ovirtC.UpdateVMParams().MustWithComment(comment),
+		ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return errors.Wrapf(err, "failed to update the comment of VM %s", vm.ID())
+	}
+	ms.logger.Infof("updated the comment of VM %s", vm.Name())
+	return nil
+}
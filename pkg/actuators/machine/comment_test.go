@@ -0,0 +1,144 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineScope_VMComment(t *testing.T) {
+	isController := true
+	testcases := []struct {
+		name     string
+		machine  *machinev1.Machine
+		expected string
+	}{
+		{
+			name: "machine of a MachineSet",
+			machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+				Namespace:         "openshift-machine-api",
+				Name:              "ocp-x7k2-worker-0-abcde",
+				UID:               "0b6c3b5e-7a8e-4b4f-9d2a-3f1c2e4d5a6b",
+				Labels:            map[string]string{utils.ClusterIDLabel: "ocp-x7k2"},
+				CreationTimestamp: v1.NewTime(time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)),
+				OwnerReferences: []v1.OwnerReference{
+					{Kind: "MachineSet", Name: "ocp-x7k2-worker-0", Controller: &isController},
+				},
+			}},
+			expected: vmCommentPrefix + " cluster=ocp-x7k2 machine=openshift-machine-api/ocp-x7k2-worker-0-abcde" +
+				" uid=0b6c3b5e-7a8e-4b4f-9d2a-3f1c2e4d5a6b machineset=ocp-x7k2-worker-0 created=2022-01-02T03:04:05Z",
+		},
+		{
+			name: "standalone machine",
+			machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+				Namespace: "openshift-machine-api",
+				Name:      "ocp-x7k2-master-0",
+			}},
+			expected: vmCommentPrefix + " machine=openshift-machine-api/ocp-x7k2-master-0",
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			ms := machineScope{machine: testcase.machine}
+			if comment := ms.vmComment(); comment != testcase.expected {
+				t.Errorf("Expected comment %q, but got %q", testcase.expected, comment)
+			}
+		})
+	}
+}
+
+func TestMachineScope_ReconcileVMComment(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	ms := machineScope{
+		Context:     context.Background(),
+		logger:      ovirt.NewKLogr("test"),
+		ovirtClient: ovirtClient,
+		machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+			Namespace: "openshift-machine-api",
+			Name:      "test-machine",
+			UID:       "0b6c3b5e-7a8e-4b4f-9d2a-3f1c2e4d5a6b",
+		}},
+	}
+	if err := ms.reconcileVMComment(vm); err != nil {
+		t.Fatalf("Unexpected error occurred while reconciling the VM comment: %v", err)
+	}
+	vm, err = ovirtClient.GetVM(vm.ID())
+	if err != nil {
+		t.Fatalf("Failed to get VM: %v", err)
+	}
+	if vm.Comment() != ms.vmComment() {
+		t.Errorf("Expected VM comment %q, but got %q", ms.vmComment(), vm.Comment())
+	}
+}
+
+func TestMachineScope_ManagedVMComment(t *testing.T) {
+	ms := machineScope{machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+		Namespace: "openshift-machine-api",
+		Name:      "test-machine",
+		UID:       "0b6c3b5e-7a8e-4b4f-9d2a-3f1c2e4d5a6b",
+	}}}
+	managed := ms.vmComment()
+	testcases := []struct {
+		name     string
+		current  string
+		expected string
+	}{
+		{
+			name:     "empty comment is replaced",
+			current:  "",
+			expected: managed,
+		},
+		{
+			name:     "outdated managed comment is replaced",
+			current:  vmCommentPrefix + " machine=openshift-machine-api/old-machine",
+			expected: managed,
+		},
+		{
+			name:     "admin comment is kept",
+			current:  "do not migrate, ticket 1234",
+			expected: "do not migrate, ticket 1234 " + managed,
+		},
+		{
+			name:     "admin comment before an outdated managed comment is kept",
+			current:  "do not migrate, ticket 1234 " + vmCommentPrefix + " machine=openshift-machine-api/old-machine",
+			expected: "do not migrate, ticket 1234 " + managed,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			comment := ms.managedVMComment(testcase.current)
+			if comment != testcase.expected {
+				t.Errorf("Expected comment %q, but got %q", testcase.expected, comment)
+			}
+			if again := ms.managedVMComment(comment); again != comment {
+				t.Errorf("Expected the managed comment to be stable, but got %q", again)
+			}
+			if !commentHasMachineUID(comment, string(ms.machine.UID)) {
+				t.Errorf("Expected the comment to carry the UID of the machine")
+			}
+		})
+	}
+}
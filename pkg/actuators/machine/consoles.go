@@ -0,0 +1,128 @@
+package machine
+
+import (
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	graphicsProtocolNone  = "none"
+	graphicsProtocolVNC   = "vnc"
+	graphicsProtocolSpice = "spice"
+)
+
+// graphicsProtocol returns the protocol of the graphics console of the VM. high_performance VMs have no graphics
+// console unless the spec sets a protocol, an empty protocol keeps the graphics consoles of the template.
+func graphicsProtocol(config *ovirtconfigv1.OvirtMachineProviderSpec) string {
+	if config.Consoles != nil && config.Consoles.GraphicsProtocol != "" {
+		return config.Consoles.GraphicsProtocol
+	}
+	// apply high_performance rules
+	// see: https://access.redhat.com/documentation/en-us/red_hat_virtualization/4.4/html-single/virtual_machine_management_guide/index?extIdCarryOver=true&sc_cid=701f2000001Css5AAC#Automatic_High_Performance_Configuration_Settings
+	if config.VMType == string(ovirtC.VMTypeHighPerformance) {
+		return graphicsProtocolNone
+	}
+	return ""
+}
+
+// reconcileGraphicsConsoles leaves the VM with a graphics console of the protocol of the spec only.
+func (ms *machineScope) reconcileGraphicsConsoles(vm ovirtC.VM) error {
+	switch protocol := graphicsProtocol(ms.machineProviderSpec); protocol {
+	case "":
+		return nil
+	case graphicsProtocolNone:
+		graphicsConsoles, err := vm.ListGraphicsConsoles()
+		if err != nil {
+			return errors.Wrapf(err, "failed to list graphics consoles")
+		}
+		for _, graphicsConsole := range graphicsConsoles {
+			err := graphicsConsole.Remove()
+			if err != nil {
+				return errors.Wrapf(err, "failed to remove graphics console '%s' from VM '%s'",
+					graphicsConsole.ID(), graphicsConsole.VMID())
+			}
+		}
+		return nil
+	default:
+		return ms.reconcileGraphicsConsoleProtocol(vm.ID(), ovirtsdk.GraphicsType(protocol))
+	}
+}
+
+// reconcileGraphicsConsoleProtocol removes the graphics consoles of other protocols from the VM and adds a graphics
+// console of the protocol if the VM has none.
+// go-ovirt-client neither exposes the protocol of a graphics console nor creates graphics consoles, so the
+// oVirt SDK is used.
+func (ms *machineScope) reconcileGraphicsConsoleProtocol(id ovirtC.VMID, protocol ovirtsdk.GraphicsType) error {
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return err
+	}
+	consolesService := conn.SystemService().VmsService().VmService(string(id)).GraphicsConsolesService()
+	response, err := consolesService.List().Send()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list graphics consoles of VM %s", id)
+	}
+	found := false
+	if consoles, ok := response.Consoles(); ok {
+		for _, console := range consoles.Slice() {
+			if consoleProtocol, _ := console.Protocol(); consoleProtocol == protocol {
+				found = true
+				continue
+			}
+			consoleID, _ := console.Id()
+			if _, err := consolesService.ConsoleService(consoleID).Remove().Send(); err != nil {
+				return errors.Wrapf(err, "failed to remove graphics console '%s' from VM '%s'", consoleID, id)
+			}
+		}
+	}
+	if found {
+		return nil
+	}
+	ms.logger.Infof("adding %s graphics console to VM %s", protocol, id)
+	console := ovirtsdk.NewGraphicsConsoleBuilder().Protocol(protocol).MustBuild()
+	if _, err := consolesService.Add().Console(console).Send(); err != nil {
+		return errors.Wrapf(err, "failed to add %s graphics console to VM %s", protocol, id)
+	}
+	return nil
+}
+
+// applyConsoleDevices enables or disables the USB support and the smartcard of the VM as set in the spec.
+// go-ovirt-client doesn't support them in its VM parameters, so they are set with the oVirt SDK once the VM
+// is cloned.
+func (ms *machineScope) applyConsoleDevices(id ovirtC.VMID) error {
+	consoles := ms.machineProviderSpec.Consoles
+	if consoles == nil || (consoles.USB == nil && consoles.Smartcard == nil) {
+		return nil
+	}
+	vm, err := consoleDevicesVM(consoles)
+	if err != nil {
+		return err
+	}
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return err
+	}
+	if _, err := conn.SystemService().VmsService().VmService(string(id)).Update().Vm(vm).Send(); err != nil {
+		return errors.Wrapf(err, "failed to update USB and smartcard of VM %s", id)
+	}
+	return nil
+}
+
+// consoleDevicesVM returns the VM update carrying the USB and smartcard settings.
+func consoleDevicesVM(consoles *ovirtconfigv1.Consoles) (*ovirtsdk.Vm, error) {
+	vmBuilder := ovirtsdk.NewVmBuilder()
+	if consoles.USB != nil {
+		vmBuilder.UsbBuilder(ovirtsdk.NewUsbBuilder().Enabled(*consoles.USB))
+	}
+	if consoles.Smartcard != nil {
+		vmBuilder.DisplayBuilder(ovirtsdk.NewDisplayBuilder().SmartcardEnabled(*consoles.Smartcard))
+	}
+	vm, err := vmBuilder.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build USB and smartcard settings")
+	}
+	return vm, nil
+}
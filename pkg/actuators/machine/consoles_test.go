@@ -0,0 +1,74 @@
+//go:build unit
+
+package machine
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+)
+
+func TestGraphicsProtocol(t *testing.T) {
+	testcases := []struct {
+		name     string
+		spec     *v1beta1.OvirtMachineProviderSpec
+		expected string
+	}{
+		{
+			name:     "server VM keeps the graphics consoles of the template",
+			spec:     &v1beta1.OvirtMachineProviderSpec{VMType: "server"},
+			expected: "",
+		},
+		{
+			name:     "high performance VM has no graphics console",
+			spec:     &v1beta1.OvirtMachineProviderSpec{VMType: "high_performance"},
+			expected: graphicsProtocolNone,
+		},
+		{
+			name: "server VM with VNC",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				VMType:   "server",
+				Consoles: &v1beta1.Consoles{GraphicsProtocol: "vnc"},
+			},
+			expected: graphicsProtocolVNC,
+		},
+		{
+			name: "high performance VM with SPICE",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				VMType:   "high_performance",
+				Consoles: &v1beta1.Consoles{GraphicsProtocol: "spice"},
+			},
+			expected: graphicsProtocolSpice,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			if protocol := graphicsProtocol(testcase.spec); protocol != testcase.expected {
+				t.Errorf("Expected graphics protocol %q, but got %q", testcase.expected, protocol)
+			}
+		})
+	}
+}
+
+func TestConsoleDevicesVM(t *testing.T) {
+	enabled, disabled := true, false
+	vm, err := consoleDevicesVM(&v1beta1.Consoles{USB: &disabled, Smartcard: &enabled})
+	if err != nil {
+		t.Fatalf("Unexpected error occurred while building the USB and smartcard settings: %v", err)
+	}
+	usb, ok := vm.Usb()
+	if !ok {
+		t.Fatalf("Expected USB settings")
+	}
+	if usbEnabled, ok := usb.Enabled(); !ok || usbEnabled {
+		t.Errorf("Expected USB to be disabled")
+	}
+	display, ok := vm.Display()
+	if !ok {
+		t.Fatalf("Expected display settings")
+	}
+	if smartcardEnabled, ok := display.SmartcardEnabled(); !ok || !smartcardEnabled {
+		t.Errorf("Expected smartcard to be enabled")
+	}
+}
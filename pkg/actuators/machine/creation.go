@@ -0,0 +1,483 @@
+package machine
+
+import (
+	"fmt"
+	"math"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+// The phases of the VM creation. Every phase only sends requests to the engine and never waits for them,
+// a phase which has to wait for the engine is executed again on the next reconcile.
+const (
+	creationPhaseCloning          = "Cloning"
+	creationPhaseConfiguringDisks = "ConfiguringDisks"
+	creationPhaseConfiguringNICs  = "ConfiguringNICs"
+	creationPhaseTagging          = "Tagging"
+	creationPhaseStarting         = "Starting"
+	creationPhaseWaitingForIP     = "WaitingForIP"
+	creationPhaseCreated          = "Created"
+	// creationPhaseRecreating is entered when a phase failed and the VM is removed to be created again
+	creationPhaseRecreating = "Recreating"
+)
+
+const (
+	creationFailurePolicyResume   = "resume"
+	creationFailurePolicyRecreate = "recreate"
+
+	// maxRecreations is the number of times a VM is recreated before the failed phase is resumed instead
+	maxRecreations = 3
+)
+
+// creationPhases are the phases of the VM creation in the order they are executed.
+var creationPhases = []string{
+	creationPhaseCloning,
+	creationPhaseConfiguringDisks,
+	creationPhaseConfiguringNICs,
+	creationPhaseTagging,
+	creationPhaseStarting,
+	creationPhaseWaitingForIP,
+	creationPhaseCreated,
+}
+
+// creationPhaseCompleted returns true if the given phase was executed before the current phase.
+func creationPhaseCompleted(current string, phase string) bool {
+	currentIndex, phaseIndex := -1, -1
+	for i, p := range creationPhases {
+		if p == current {
+			currentIndex = i
+		}
+		if p == phase {
+			phaseIndex = i
+		}
+	}
+	return phaseIndex >= 0 && currentIndex > phaseIndex
+}
+
+// creationPhaseFunc executes a phase of the VM creation on the VM. It returns the next phase,
+// or the same phase if the phase has to wait for the engine.
+type creationPhaseFunc func(vm ovirtC.VM) (string, error)
+
+func (ms *machineScope) creationPhaseFuncs() map[string]creationPhaseFunc {
+	return map[string]creationPhaseFunc{
+		creationPhaseCloning:          ms.reconcileCloning,
+		creationPhaseConfiguringDisks: ms.reconcileDisks,
+		creationPhaseConfiguringNICs:  ms.reconcileNICs,
+		creationPhaseTagging:          ms.reconcileTags,
+		creationPhaseStarting:         ms.reconcileStart,
+		creationPhaseWaitingForIP:     ms.reconcileIP,
+	}
+}
+
+// creationPhase returns the recorded creation phase of the machine. Machines without a recorded phase
+// are considered created, unless they never got a provider ID, which means the phase of a VM which was
+// just cloned could not be recorded.
+func (ms *machineScope) creationPhase() (string, error) {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return "", errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	if providerStatus.CreationPhase != "" {
+		return providerStatus.CreationPhase, nil
+	}
+	if ms.machine.Spec.ProviderID == nil {
+		return creationPhaseCloning, nil
+	}
+	return creationPhaseCreated, nil
+}
+
+func (ms *machineScope) setCreationPhase(phase string) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	providerStatus.CreationPhase = phase
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
+
+// isCreated returns true if all phases of the VM creation are completed.
+func (ms *machineScope) isCreated() (bool, error) {
+	phase, err := ms.creationPhase()
+	if err != nil {
+		return false, err
+	}
+	return phase == creationPhaseCreated, nil
+}
+
+// reconcileCreation executes the phases of the VM creation, starting at the recorded phase, until a phase
+// has to wait for the engine. The reached phase is recorded in the provider status.
+func (ms *machineScope) reconcileCreation() error {
+	phase, err := ms.creationPhase()
+	if err != nil {
+		return err
+	}
+	phaseFuncs := ms.creationPhaseFuncs()
+	for phase != creationPhaseCreated {
+		phaseFunc, ok := phaseFuncs[phase]
+		if !ok {
+			return fmt.Errorf("unknown creation phase %s", phase)
+		}
+		vm, err := ms.getVM()
+		if err != nil {
+			return err
+		}
+		nextPhase, err := phaseFunc(vm)
+		if err != nil {
+			recordedPhase := phase
+			if ms.shouldRecreate() {
+				ms.logger.Errorf("creation phase %s of VM %s failed, recreating the VM: %v", phase, vm.Name(), err)
+				recordedPhase = creationPhaseRecreating
+			}
+			if setErr := ms.setCreationPhase(recordedPhase); setErr != nil {
+				ms.logger.Errorf("failed to record creation phase %s: %v", recordedPhase, setErr)
+			}
+			return errors.Wrapf(err, "error in creation phase %s", phase)
+		}
+		if nextPhase == phase {
+			ms.logger.Infof("VM %s is waiting in creation phase %s", vm.Name(), phase)
+			break
+		}
+		ms.logger.Infof("VM %s moved from creation phase %s to %s", vm.Name(), phase, nextPhase)
+		phase = nextPhase
+	}
+	return ms.setCreationPhase(phase)
+}
+
+// shouldRecreate returns true if a VM whose creation failed is removed and created again.
+func (ms *machineScope) shouldRecreate() bool {
+	if ms.machineProviderSpec == nil || ms.machineProviderSpec.CreationFailurePolicy != creationFailurePolicyRecreate {
+		return false
+	}
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return false
+	}
+	if providerStatus.Recreations >= maxRecreations {
+		ms.logger.Infof("VM of machine %s was already recreated %d times, resuming the creation", ms.machine.Name,
+			providerStatus.Recreations)
+		return false
+	}
+	return true
+}
+
+// reconcileRecreation removes the VM of a machine in the Recreating phase. Once the VM is removed, the machine
+// forgets it, so the VM is created again from scratch. It returns true as long as the VM is recreated.
+func (ms *machineScope) reconcileRecreation() (bool, error) {
+	phase, err := ms.creationPhase()
+	if err != nil {
+		return false, err
+	}
+	if phase != creationPhaseRecreating {
+		return false, nil
+	}
+
+	vm, err := ms.getVM()
+	if err != nil && !isVMNotFound(err) {
+		return true, err
+	}
+	if vm != nil {
+		if err := ms.verifyOwnership(vm); err != nil {
+			return true, err
+		}
+		switch vm.Status() {
+		case ovirtC.VMStatusDown:
+			if err := ms.removeUnattachedAdditionalDisks(vm); err != nil {
+				return true, err
+			}
+			if err := vm.Remove(ovirtC.ContextStrategy(ms.Context)); err != nil && !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+				return true, errors.Wrapf(err, "failed to remove VM %s for its recreation", vm.ID())
+			}
+			ms.logger.Infof("removed VM %s(%s) to create it again", vm.Name(), vm.ID())
+		case ovirtC.VMStatusPoweringDown:
+			return true, nil
+		default:
+			if err := vm.Stop(true, ovirtC.ContextStrategy(ms.Context)); err != nil {
+				return true, errors.Wrapf(err, "failed to stop VM %s for its recreation", vm.ID())
+			}
+			return true, nil
+		}
+	} else if err := ms.removeUnattachedAdditionalDisks(nil); err != nil {
+		return true, err
+	}
+
+	// the machine must not keep the provider ID of the removed VM, otherwise it is considered failed
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return true, errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	providerStatus.InstanceID = nil
+	providerStatus.InstanceState = nil
+	providerStatus.CreationPhase = ""
+	providerStatus.TemplateNICCount = nil
+	providerStatus.AdditionalDiskIDs = nil
+	providerStatus.Recreations++
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return true, errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	ms.machine.Spec.ProviderID = nil
+	ms.machine.Status.Addresses = nil
+	delete(ms.machine.Annotations, utils.OvirtIDAnnotationKey)
+	delete(ms.machine.Annotations, InstanceStatusAnnotationKey)
+	return true, nil
+}
+
+// reconcileCloning waits until the template is cloned and applies the high availability settings, the
+// watchdog and the USB and smartcard settings to the VM.
+func (ms *machineScope) reconcileCloning(vm ovirtC.VM) (string, error) {
+	switch vm.Status() {
+	case ovirtC.VMStatusImageLocked:
+		return creationPhaseCloning, nil
+	case ovirtC.VMStatusDown:
+		if err := ms.applyHighAvailability(vm.ID()); err != nil {
+			return "", err
+		}
+		if err := ms.reconcileWatchdog(vm.ID()); err != nil {
+			return "", err
+		}
+		if err := ms.applyConsoleDevices(vm.ID()); err != nil {
+			return "", err
+		}
+		return creationPhaseConfiguringDisks, nil
+	default:
+		// the VM was already started
+		return creationPhaseStarting, nil
+	}
+}
+
+// reconcileDisks configures the graphics consoles, extends the OS disk and creates the additional disks.
+func (ms *machineScope) reconcileDisks(vm ovirtC.VM) (string, error) {
+	if err := ms.reconcileGraphicsConsoles(vm); err != nil {
+		return "", err
+	}
+
+	osDiskReady, err := ms.reconcileOSDisk(vm)
+	if err != nil {
+		return "", err
+	}
+	if !osDiskReady {
+		return creationPhaseConfiguringDisks, nil
+	}
+
+	additionalDisksReady, err := ms.reconcileAdditionalDisks(vm)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to create additional disks for VM %s", vm.ID())
+	}
+	if !additionalDisksReady {
+		return creationPhaseConfiguringDisks, nil
+	}
+	return creationPhaseConfiguringNICs, nil
+}
+
+// reconcileOSDisk extends the bootable disk of the VM to the size of the OS disk. It returns true
+// once the disk has the size and is ready, the size is then recorded in the provider status.
+func (ms *machineScope) reconcileOSDisk(vm ovirtC.VM) (bool, error) {
+	if ms.machineProviderSpec.OSDisk == nil {
+		return true, nil
+	}
+	disk, err := ms.bootableDisk(vm)
+	if err != nil {
+		return false, err
+	}
+	if disk.Status() != ovirtC.DiskStatusOK {
+		ms.logger.Infof("waiting for disk %s to become OK...", disk.ID())
+		return false, nil
+	}
+
+	newDiskSize := uint64(ms.machineProviderSpec.OSDisk.SizeGB * int64(math.Pow(2, 30)))
+	if newDiskSize > disk.ProvisionedSize() {
+		if _, err := disk.StartUpdate(ovirtC.UpdateDiskParams().MustWithProvisionedSize(newDiskSize)); err != nil {
+			return false, errors.Wrapf(err, "failed to extend disk %s", disk.ID())
+		}
+		ms.logger.Infof("extending disk %s to %d bytes", disk.ID(), newDiskSize)
+		return false, nil
+	}
+	return true, ms.setOSDiskSize(ms.machineProviderSpec.OSDisk.SizeGB)
+}
+
+// bootableDisk returns the bootable disk of the VM.
+func (ms *machineScope) bootableDisk(vm ovirtC.VM) (ovirtC.Disk, error) {
+	diskAttachments, err := vm.ListDiskAttachments()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list disk attachments for VM %s.", vm.ID())
+	}
+	var bootableDiskAttachment ovirtC.DiskAttachment
+	for _, diskAttachment := range diskAttachments {
+		if diskAttachment.Bootable() {
+			bootableDiskAttachment = diskAttachment
+		}
+	}
+	if bootableDiskAttachment == nil {
+		return nil, fmt.Errorf("VM %s(%s) doesn't have a bootable disk", vm.Name(), vm.ID())
+	}
+	return ms.ovirtClient.GetDisk(bootableDiskAttachment.DiskID(), ovirtC.ContextStrategy(ms.Context))
+}
+
+// reconcileNICs replaces or extends the network interfaces of the template with the ones of the machine spec
+// and applies the CPU pinning.
+func (ms *machineScope) reconcileNICs(vm ovirtC.VM) (string, error) {
+	if len(ms.machineProviderSpec.NetworkInterfaces) > 0 {
+		nics, err := vm.ListNICs()
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to list NICs on VM %s", vm.ID())
+		}
+
+		existingNICs := 0
+		if ms.machineProviderSpec.NetworkInterfacesMode == networkInterfacesModeAppend {
+			// keep the nics of the template and add the new ones after them, nics created by a failed
+			// attempt of the phase are kept as well
+			existingNICs, err = ms.templateNICCount(nics)
+			if err != nil {
+				return "", err
+			}
+		} else {
+			//remove all the nics from the VM instance
+			for _, nic := range nics {
+				if err := nic.Remove(); err != nil {
+					return "", errors.Wrapf(err, "failed to remove NIC %s", nic.ID())
+				}
+			}
+			nics = nil
+		}
+		vmNICNames := make(map[string]bool, len(nics))
+		for _, nic := range nics {
+			vmNICNames[nic.Name()] = true
+		}
+
+		//re-create NICs According to the machinespec
+		for i, name := range ms.specNICNames(existingNICs) {
+			if vmNICNames[name] {
+				continue
+			}
+			nic := ms.machineProviderSpec.NetworkInterfaces[i]
+			if err := ms.createNIC(vm, name, nic); err != nil {
+				return "", errors.Wrapf(err, "failed to create NIC %s on VM %s", name, vm.ID())
+			}
+		}
+	}
+
+	if ms.isAutoPinning() {
+		err := ms.ovirtClient.AutoOptimizeVMCPUPinningSettings(vm.ID(), true, ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return "", err
+		}
+	}
+	return creationPhaseTagging, nil
+}
+
+// specNICNames returns the names of the network interfaces of the spec. Interfaces without a name are named
+// after their position behind the given number of existing interfaces.
+func (ms *machineScope) specNICNames(existingNICs int) []string {
+	names := make([]string, len(ms.machineProviderSpec.NetworkInterfaces))
+	for i, nic := range ms.machineProviderSpec.NetworkInterfaces {
+		names[i] = nic.Name
+		if names[i] == "" {
+			names[i] = fmt.Sprintf("nic%d", existingNICs+i+1)
+		}
+	}
+	return names
+}
+
+// templateNICCount returns the number of network interfaces the VM got from the template. The count is recorded
+// in the provider status the first time, as a resumed phase finds the interfaces it created before as well.
+func (ms *machineScope) templateNICCount(nics []ovirtC.NIC) (int, error) {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return 0, errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	if providerStatus.TemplateNICCount != nil {
+		return int(*providerStatus.TemplateNICCount), nil
+	}
+	count := int32(len(nics))
+	providerStatus.TemplateNICCount = &count
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return 0, errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return len(nics), nil
+}
+
+// reconcileTags tags the VM with the cluster tag and the tags of the machine spec and adds it to the existing
+// and the declared affinity groups.
+func (ms *machineScope) reconcileTags(vm ovirtC.VM) (string, error) {
+	// the phase is resumed after a failure, the engine rejects adding a tag or a group member twice
+	clusterTag := ms.machine.Labels[utils.ClusterIDLabel]
+	tags, err := ms.ovirtClient.ListVMTags(vm.ID(), ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list tags of VM %s", vm.ID())
+	}
+	tagged := false
+	for _, tag := range tags {
+		if tag.Name() == clusterTag {
+			tagged = true
+			break
+		}
+	}
+	if !tagged {
+		err := ms.ovirtClient.AddTagToVMByName(vm.ID(), clusterTag, ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return "", err
+		}
+	}
+
+	for _, agName := range ms.machineProviderSpec.AffinityGroupsNames {
+		ag, err := ms.ovirtClient.GetAffinityGroupByName(ovirtC.ClusterID(ms.machineProviderSpec.ClusterId), agName, ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return "", err
+		}
+		if affinityGroupHasVM(ag, vm.ID()) {
+			continue
+		}
+		err = ag.AddVM(vm.ID())
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := ms.reconcileVMTags(vm); err != nil {
+		return "", err
+	}
+	if err := ms.reconcileAffinityGroups(vm); err != nil {
+		return "", err
+	}
+	return creationPhaseStarting, nil
+}
+
+// reconcileStart starts the VM and waits until it is up.
+func (ms *machineScope) reconcileStart(vm ovirtC.VM) (string, error) {
+	switch vm.Status() {
+	case ovirtC.VMStatusUp:
+		return creationPhaseWaitingForIP, nil
+	case ovirtC.VMStatusDown:
+		if err := ms.ovirtClient.StartVM(vm.ID(), ovirtC.ContextStrategy(ms.Context)); err != nil {
+			return "", errors.Wrap(err, "error running oVirt VM")
+		}
+	}
+	return creationPhaseStarting, nil
+}
+
+// reconcileIP waits until the guest reports a usable IP address.
+func (ms *machineScope) reconcileIP(vm ovirtC.VM) (string, error) {
+	if vm.Status() != ovirtC.VMStatusUp {
+		return creationPhaseWaitingForIP, nil
+	}
+	excludeAddr, err := ms.getClusterAddress(ms.Context)
+	if err != nil {
+		return "", errors.Wrap(err, "error getting cluster address")
+	}
+	ip, err := ms.findUsableInternalAddress(ms.Context, string(vm.ID()), excludeAddr)
+	if err != nil || ip == "" {
+		ms.logger.Infof("VM %s has no usable IP address yet: %v", vm.Name(), err)
+		return creationPhaseWaitingForIP, nil
+	}
+	return creationPhaseCreated, nil
+}
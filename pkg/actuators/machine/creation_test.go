@@ -0,0 +1,345 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineScope_CreationPhase(t *testing.T) {
+	providerID := "ovirt://1234"
+	testcases := []struct {
+		name          string
+		recordedPhase string
+		providerID    *string
+		expected      string
+	}{
+		{
+			name:          "recorded phase is returned",
+			recordedPhase: creationPhaseConfiguringNICs,
+			providerID:    &providerID,
+			expected:      creationPhaseConfiguringNICs,
+		},
+		{
+			name:       "machine with provider ID but without phase is created",
+			providerID: &providerID,
+			expected:   creationPhaseCreated,
+		},
+		{
+			name:     "machine without provider ID and phase is cloning",
+			expected: creationPhaseCloning,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			ms := machineScope{machine: &machinev1.Machine{}}
+			ms.machine.Spec.ProviderID = testcase.providerID
+			if testcase.recordedPhase != "" {
+				if err := ms.setCreationPhase(testcase.recordedPhase); err != nil {
+					t.Fatalf("Unexpected error occurred while recording the creation phase: %v", err)
+				}
+			}
+
+			phase, err := ms.creationPhase()
+			if err != nil {
+				t.Fatalf("Unexpected error occurred while reading the creation phase: %v", err)
+			}
+			if phase != testcase.expected {
+				t.Errorf("Expected creation phase to be %s, but got %s", testcase.expected, phase)
+			}
+		})
+	}
+}
+
+func TestMachineScope_ReconcileCreation(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	if _, err := ovirtClient.CreateTag("test-cluster", ovirtclient.NewCreateTagParams()); err != nil {
+		t.Fatalf("Failed to create cluster tag: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine",
+		ovirtclient.NewCreateVMParams().MustWithComment(vmCommentPrefix+" uid=test-uid"))
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+	spec.OSDisk = nil
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		ovirtClient:         ovirtClient,
+		machineProviderSpec: spec,
+		machine: &machinev1.Machine{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   "test-machine",
+				UID:    "test-uid",
+				Labels: map[string]string{"machine.openshift.io/cluster-api-cluster": "test-cluster"},
+			},
+		},
+	}
+	if err := ms.setCreationPhase(creationPhaseCloning); err != nil {
+		t.Fatalf("Unexpected error occurred while recording the creation phase: %v", err)
+	}
+
+	// the phases run until the VM is started, which doesn't complete immediately
+	if err := ms.reconcileCreation(); err != nil {
+		t.Fatalf("Unexpected error occurred while reconciling the creation: %v", err)
+	}
+	providerStatus, err := v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		t.Fatalf("Failed to read provider status: %v", err)
+	}
+	if providerStatus.CreationPhase != creationPhaseStarting {
+		t.Errorf("Expected creation phase to be %s, but got %s", creationPhaseStarting, providerStatus.CreationPhase)
+	}
+
+	tags, err := ovirtClient.ListVMTags(vm.ID())
+	if err != nil {
+		t.Fatalf("Failed to list VM tags: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name() != "test-cluster" {
+		t.Errorf("Expected VM to be tagged with the cluster tag, but got %v", tags)
+	}
+
+	vm, err = ovirtClient.GetVM(vm.ID())
+	if err != nil {
+		t.Fatalf("Failed to get VM: %v", err)
+	}
+	if vm.Status() == ovirtclient.VMStatusDown {
+		t.Errorf("Expected VM to be starting, but it is %s", vm.Status())
+	}
+}
+
+func TestMachineScope_CreationFailurePolicy(t *testing.T) {
+	testcases := []struct {
+		name          string
+		policy        string
+		expectedPhase string
+		expectRemoved bool
+	}{
+		{
+			name:          "failed phase is resumed by default",
+			policy:        "",
+			expectedPhase: creationPhaseTagging,
+		},
+		{
+			name:          "VM is recreated with the recreate policy",
+			policy:        creationFailurePolicyRecreate,
+			expectedPhase: creationPhaseRecreating,
+			expectRemoved: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+			if err != nil {
+				t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+			}
+			ovirtClient := helper.GetClient()
+			template, err := ovirtClient.GetBlankTemplate()
+			if err != nil {
+				t.Fatalf("Failed to get blank template: %v", err)
+			}
+			vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine",
+				ovirtclient.NewCreateVMParams().MustWithComment(vmCommentPrefix+" uid=test-uid"))
+			if err != nil {
+				t.Fatalf("Failed to create VM: %v", err)
+			}
+
+			spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+			spec.CreationFailurePolicy = testcase.policy
+			providerID := utils.ProviderIDPrefix + string(vm.ID())
+			ms := machineScope{
+				Context:             context.Background(),
+				logger:              ovirt.NewKLogr("test"),
+				ovirtClient:         ovirtClient,
+				machineProviderSpec: spec,
+				machine: &machinev1.Machine{
+					ObjectMeta: v1.ObjectMeta{
+						Name: "test-machine",
+						UID:  "test-uid",
+						// the tag doesn't exist, so the tagging phase fails
+						Labels: map[string]string{utils.ClusterIDLabel: "missing-cluster"},
+					},
+					Spec: machinev1.MachineSpec{ProviderID: &providerID},
+				},
+			}
+			if err := ms.setCreationPhase(creationPhaseTagging); err != nil {
+				t.Fatalf("Unexpected error occurred while recording the creation phase: %v", err)
+			}
+
+			if err := ms.reconcileCreation(); err == nil {
+				t.Fatalf("Expected the tagging phase to fail")
+			}
+			phase, err := ms.creationPhase()
+			if err != nil {
+				t.Fatalf("Unexpected error occurred while reading the creation phase: %v", err)
+			}
+			if phase != testcase.expectedPhase {
+				t.Fatalf("Expected creation phase to be %s, but got %s", testcase.expectedPhase, phase)
+			}
+
+			recreating, err := ms.reconcileRecreation()
+			if err != nil {
+				t.Fatalf("Unexpected error occurred while recreating the VM: %v", err)
+			}
+			if recreating != testcase.expectRemoved {
+				t.Fatalf("Expected recreating to be %t, but got %t", testcase.expectRemoved, recreating)
+			}
+			_, err = ovirtClient.GetVM(vm.ID())
+			if removed := err != nil && ovirtclient.HasErrorCode(err, ovirtclient.ENotFound); removed != testcase.expectRemoved {
+				t.Errorf("Expected VM removal to be %t, but got error %v", testcase.expectRemoved, err)
+			}
+			if testcase.expectRemoved {
+				if ms.machine.Spec.ProviderID != nil {
+					t.Errorf("Expected the provider ID of the removed VM to be cleared, but got %s", *ms.machine.Spec.ProviderID)
+				}
+				providerStatus, err := v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+				if err != nil {
+					t.Fatalf("Failed to read provider status: %v", err)
+				}
+				if providerStatus.InstanceID != nil || providerStatus.Recreations != 1 {
+					t.Errorf("Expected the instance ID to be cleared and 1 recreation, but got %v and %d",
+						providerStatus.InstanceID, providerStatus.Recreations)
+				}
+			}
+		})
+	}
+}
+
+func TestMachineScope_ReconcileNICsAppendResumed(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	// nic1 comes from the template, nic2 was created by a failed attempt of the phase
+	for _, name := range []string{"nic1", "nic2"} {
+		if _, err := vm.CreateNIC(name, helper.GetVNICProfileID(), nil); err != nil {
+			t.Fatalf("Failed to create NIC: %v", err)
+		}
+	}
+
+	spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+	spec.NetworkInterfacesMode = networkInterfacesModeAppend
+	spec.NetworkInterfaces = []*v1beta1.NetworkInterface{
+		{VNICProfileID: string(helper.GetVNICProfileID())},
+		{VNICProfileID: string(helper.GetVNICProfileID())},
+		{Name: "storage", VNICProfileID: string(helper.GetVNICProfileID())},
+	}
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		ovirtClient:         ovirtClient,
+		machineProviderSpec: spec,
+		machine:             &machinev1.Machine{ObjectMeta: v1.ObjectMeta{Name: "test-machine"}},
+	}
+	templateNICCount := int32(1)
+	providerStatus, err := v1beta1.RawExtensionFromProviderStatus(
+		&v1beta1.OvirtMachineProviderStatus{TemplateNICCount: &templateNICCount})
+	if err != nil {
+		t.Fatalf("Failed to build provider status: %v", err)
+	}
+	ms.machine.Status.ProviderStatus = providerStatus
+
+	for i := 0; i < 2; i++ {
+		if _, err := ms.reconcileNICs(vm); err != nil {
+			t.Fatalf("Unexpected error occurred while reconciling the NICs: %v", err)
+		}
+	}
+	nics, err := vm.ListNICs()
+	if err != nil {
+		t.Fatalf("Failed to list NICs: %v", err)
+	}
+	names := map[string]int{}
+	for _, nic := range nics {
+		names[nic.Name()]++
+	}
+	expected := map[string]int{"nic1": 1, "nic2": 1, "nic3": 1, "storage": 1}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected NICs %v, but got %v", expected, names)
+	}
+}
+
+func TestMachineScope_ReconcileTagsResumed(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	if _, err := ovirtClient.CreateTag("test-cluster", ovirtclient.NewCreateTagParams()); err != nil {
+		t.Fatalf("Failed to create cluster tag: %v", err)
+	}
+	if _, err := ovirtClient.CreateAffinityGroup(helper.GetClusterID(), "workers", nil); err != nil {
+		t.Fatalf("Failed to create affinity group: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+	spec.AffinityGroupsNames = []string{"workers"}
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		ovirtClient:         ovirtClient,
+		machineProviderSpec: spec,
+		machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+			Name:   "test-machine",
+			Labels: map[string]string{utils.ClusterIDLabel: "test-cluster"},
+		}},
+	}
+
+	// the second run resumes the phase after the VM was already tagged and added to the group
+	for i := 0; i < 2; i++ {
+		if _, err := ms.reconcileTags(vm); err != nil {
+			t.Fatalf("Unexpected error occurred while reconciling the tags: %v", err)
+		}
+	}
+	tags, err := ovirtClient.ListVMTags(vm.ID())
+	if err != nil {
+		t.Fatalf("Failed to list VM tags: %v", err)
+	}
+	if len(tags) != 1 || tags[0].Name() != "test-cluster" {
+		t.Errorf("Expected VM to be tagged once with the cluster tag, but got %v", tags)
+	}
+	ag, err := ovirtClient.GetAffinityGroupByName(helper.GetClusterID(), "workers")
+	if err != nil {
+		t.Fatalf("Failed to get affinity group: %v", err)
+	}
+	if len(ag.VMIDs()) != 1 {
+		t.Errorf("Expected VM to be in the affinity group once, but got %v", ag.VMIDs())
+	}
+}
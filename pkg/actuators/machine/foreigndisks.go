@@ -0,0 +1,98 @@
+package machine
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+// detachForeignDisks detaches the disks which don't belong to the VM, so they are kept when the VM is removed
+// together with its disks. A disk belongs to the VM if it is the bootable disk, was cloned from the template
+// or is an additional disk of the machine. Disks of the oVirt CSI driver never belong to the VM.
+// The additional disks are identified by the disk IDs recorded in the provider status, the alias of the additional
+// disks is only used for machines created before the IDs were recorded.
+// It returns the aliases of the detached disks.
+func (ms *machineScope) detachForeignDisks(vm ovirtC.VM) ([]string, error) {
+	diskAttachments, err := vm.ListDiskAttachments(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list disk attachments for VM %s", vm.ID())
+	}
+	templateDiskAliases, err := ms.templateDiskAliases(vm)
+	if err != nil {
+		return nil, err
+	}
+	isAdditionalDisk, err := ms.additionalDiskMatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	var detached []string
+	for _, diskAttachment := range diskAttachments {
+		disk, err := diskAttachment.Disk(ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return detached, errors.Wrapf(err, "failed to fetch disk %s", diskAttachment.DiskID())
+		}
+		alias := disk.Alias()
+		if !strings.HasPrefix(alias, utils.CSIDiskAliasPrefix) &&
+			(diskAttachment.Bootable() || templateDiskAliases[alias] || isAdditionalDisk(disk)) {
+			continue
+		}
+		if err := diskAttachment.Remove(ovirtC.ContextStrategy(ms.Context)); err != nil {
+			return detached, errors.Wrapf(err, "failed to detach disk %s(%s) from VM %s", alias, disk.ID(), vm.ID())
+		}
+		ms.logger.Infof("detached disk %s(%s) from VM %s to preserve it", alias, disk.ID(), vm.Name())
+		detached = append(detached, fmt.Sprintf("%s(%s)", alias, disk.ID()))
+	}
+	return detached, nil
+}
+
+// additionalDiskMatcher returns a function matching the additional disks of the machine, by their recorded IDs,
+// or by their alias if the machine didn't record any ID.
+func (ms *machineScope) additionalDiskMatcher() (func(disk ovirtC.Disk) bool, error) {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	diskIDs := map[ovirtC.DiskID]bool{}
+	for _, id := range providerStatus.AdditionalDiskIDs {
+		if id != "" {
+			diskIDs[ovirtC.DiskID(id)] = true
+		}
+	}
+	if len(diskIDs) > 0 {
+		return func(disk ovirtC.Disk) bool {
+			return diskIDs[disk.ID()]
+		}, nil
+	}
+	additionalDiskAlias := regexp.MustCompile(fmt.Sprintf(`^%s_data\d+$`, regexp.QuoteMeta(ms.machine.Name)))
+	return func(disk ovirtC.Disk) bool {
+		return additionalDiskAlias.MatchString(disk.Alias())
+	}, nil
+}
+
+// templateDiskAliases returns the aliases of the disks of the template the VM was cloned from.
+// The disks of a VM keep the alias of the template disk they were cloned from.
+func (ms *machineScope) templateDiskAliases(vm ovirtC.VM) (map[string]bool, error) {
+	aliases := map[string]bool{}
+	templateDiskAttachments, err := ms.ovirtClient.ListTemplateDiskAttachments(vm.TemplateID(), ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		if ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+			// without the template only the bootable and the additional disks are known to belong to the VM
+			return aliases, nil
+		}
+		return nil, errors.Wrapf(err, "failed to list disk attachments of template %s", vm.TemplateID())
+	}
+	for _, templateDiskAttachment := range templateDiskAttachments {
+		disk, err := ms.ovirtClient.GetDisk(templateDiskAttachment.DiskID(), ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to fetch template disk %s", templateDiskAttachment.DiskID())
+		}
+		aliases[disk.Alias()] = true
+	}
+	return aliases, nil
+}
@@ -0,0 +1,138 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineScope_DetachForeignDisks(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	attached := map[string]bool{
+		"test-machine_data1": false,
+		"pvc-0a1b2c3d":       true,
+		"manually-attached":  true,
+	}
+	for alias := range attached {
+		disk, err := ovirtClient.CreateDisk(
+			helper.GetStorageDomainID(),
+			ovirtclient.ImageFormatCow,
+			bytesInGB,
+			ovirtclient.CreateDiskParams().MustWithAlias(alias))
+		if err != nil {
+			t.Fatalf("Failed to create disk %s: %v", alias, err)
+		}
+		if _, err := vm.AttachDisk(disk.ID(), ovirtclient.DiskInterfaceVirtIOSCSI, nil); err != nil {
+			t.Fatalf("Failed to attach disk %s: %v", alias, err)
+		}
+	}
+
+	ms := machineScope{
+		Context:     context.Background(),
+		logger:      ovirt.NewKLogr("test"),
+		ovirtClient: ovirtClient,
+		machine:     &machinev1.Machine{ObjectMeta: v1.ObjectMeta{Name: "test-machine"}},
+	}
+	detached, err := ms.detachForeignDisks(vm)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred while detaching foreign disks: %v", err)
+	}
+	sort.Strings(detached)
+	if len(detached) != 2 || !strings.HasPrefix(detached[0], "manually-attached(") ||
+		!strings.HasPrefix(detached[1], "pvc-0a1b2c3d(") {
+		t.Errorf("Expected the CSI and the manually attached disk to be detached, but got %v", detached)
+	}
+
+	diskAttachments, err := vm.ListDiskAttachments()
+	if err != nil {
+		t.Fatalf("Failed to list disk attachments: %v", err)
+	}
+	if len(diskAttachments) != 1 {
+		t.Fatalf("Expected only the additional disk to stay attached, but got %d disks", len(diskAttachments))
+	}
+	disk, err := diskAttachments[0].Disk()
+	if err != nil {
+		t.Fatalf("Failed to get disk: %v", err)
+	}
+	if disk.Alias() != "test-machine_data1" {
+		t.Errorf("Expected the additional disk to stay attached, but got %s", disk.Alias())
+	}
+}
+
+func TestMachineScope_DetachForeignDisksByRecordedIDs(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	// the second disk has the alias of an additional disk, but it wasn't created by the machine
+	diskIDs := map[string]ovirtclient.DiskID{}
+	for _, alias := range []string{"test-machine_data1", "test-machine_data2"} {
+		disk, err := ovirtClient.CreateDisk(
+			helper.GetStorageDomainID(),
+			ovirtclient.ImageFormatCow,
+			bytesInGB,
+			ovirtclient.CreateDiskParams().MustWithAlias(alias))
+		if err != nil {
+			t.Fatalf("Failed to create disk %s: %v", alias, err)
+		}
+		if _, err := vm.AttachDisk(disk.ID(), ovirtclient.DiskInterfaceVirtIOSCSI, nil); err != nil {
+			t.Fatalf("Failed to attach disk %s: %v", alias, err)
+		}
+		diskIDs[alias] = disk.ID()
+	}
+	providerStatus, err := v1beta1.RawExtensionFromProviderStatus(&v1beta1.OvirtMachineProviderStatus{
+		AdditionalDiskIDs: []string{string(diskIDs["test-machine_data1"])},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build provider status: %v", err)
+	}
+
+	ms := machineScope{
+		Context:     context.Background(),
+		logger:      ovirt.NewKLogr("test"),
+		ovirtClient: ovirtClient,
+		machine: &machinev1.Machine{
+			ObjectMeta: v1.ObjectMeta{Name: "test-machine"},
+			Status:     machinev1.MachineStatus{ProviderStatus: providerStatus},
+		},
+	}
+	detached, err := ms.detachForeignDisks(vm)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred while detaching foreign disks: %v", err)
+	}
+	if len(detached) != 1 || !strings.HasPrefix(detached[0], "test-machine_data2(") {
+		t.Errorf("Expected the disk not recorded by the machine to be detached, but got %v", detached)
+	}
+}
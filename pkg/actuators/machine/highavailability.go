@@ -0,0 +1,103 @@
+package machine
+
+import (
+	"fmt"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	resumeBehaviourAutoResume  = "auto_resume"
+	resumeBehaviourLeavePaused = "leave_paused"
+	resumeBehaviourKill        = "kill"
+)
+
+// applyHighAvailability applies the high availability settings of the machine spec to the VM.
+// go-ovirt-client doesn't support the high availability, the lease and the resume behaviour of a VM in its
+// VM parameters, so they are set with the oVirt SDK once the VM is cloned.
+func (ms *machineScope) applyHighAvailability(id ovirtC.VMID) error {
+	ha := ms.machineProviderSpec.HighAvailability
+	if ha == nil {
+		return nil
+	}
+	vm, err := highAvailabilityVM(ha)
+	if err != nil {
+		return err
+	}
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return err
+	}
+	ms.logger.Infof("applying high availability settings to VM %s", id)
+	if _, err := conn.SystemService().VmsService().VmService(string(id)).Update().Vm(vm).Send(); err != nil {
+		return errors.Wrapf(err, "failed to apply high availability settings to VM %s", id)
+	}
+	return nil
+}
+
+// highAvailabilityVM returns the VM update carrying the high availability settings.
+func highAvailabilityVM(ha *ovirtconfigv1.HighAvailability) (*ovirtsdk.Vm, error) {
+	haBuilder := ovirtsdk.NewHighAvailabilityBuilder().Enabled(ha.Enabled)
+	if ha.Priority != 0 {
+		haBuilder.Priority(int64(ha.Priority))
+	}
+	vmBuilder := ovirtsdk.NewVmBuilder().HighAvailabilityBuilder(haBuilder)
+	if ha.LeaseStorageDomainID != "" {
+		vmBuilder.LeaseBuilder(ovirtsdk.NewStorageDomainLeaseBuilder().
+			StorageDomainBuilder(ovirtsdk.NewStorageDomainBuilder().Id(ha.LeaseStorageDomainID)))
+	}
+	if ha.ResumeBehaviour != "" {
+		vmBuilder.StorageErrorResumeBehaviour(ovirtsdk.VmStorageErrorResumeBehaviour(ha.ResumeBehaviour))
+	}
+	vm, err := vmBuilder.Build()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build high availability settings")
+	}
+	return vm, nil
+}
+
+// leaseStorageDomainInDatacenter returns an error if the lease storage domain isn't attached to the datacenter
+// of the cluster. Failed requests to the engine are returned as engineError.
+// go-ovirt-client doesn't expose the datacenter of a cluster nor the storage domains of a datacenter, so the
+// oVirt SDK is used.
+func leaseStorageDomainInDatacenter(ovirtClient ovirtC.Client, clusterID string, storageDomainID string) error {
+	conn, err := ovirt.GetSDKConnection(ovirtClient)
+	if err != nil {
+		return newEngineError(err, "failed to get SDK connection")
+	}
+	clusterResponse, err := conn.SystemService().ClustersService().ClusterService(clusterID).Get().Send()
+	if err != nil {
+		var notFound *ovirtsdk.NotFoundError
+		if errors.As(err, &notFound) {
+			return fmt.Errorf("cluster %s not found", clusterID)
+		}
+		return newEngineError(err, fmt.Sprintf("failed to get cluster %s", clusterID))
+	}
+	cluster, ok := clusterResponse.Cluster()
+	if !ok {
+		return fmt.Errorf("cluster %s not found", clusterID)
+	}
+	datacenter, ok := cluster.DataCenter()
+	if !ok {
+		return fmt.Errorf("cluster %s has no datacenter", clusterID)
+	}
+	datacenterID, _ := datacenter.Id()
+	storageDomainsResponse, err := conn.SystemService().DataCentersService().DataCenterService(datacenterID).
+		StorageDomainsService().List().Send()
+	if err != nil {
+		return newEngineError(err, fmt.Sprintf("failed to list storage domains of datacenter %s", datacenterID))
+	}
+	if storageDomains, ok := storageDomainsResponse.StorageDomains(); ok {
+		for _, storageDomain := range storageDomains.Slice() {
+			if id, _ := storageDomain.Id(); id == storageDomainID {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("lease storage domain %s is not attached to datacenter %s of cluster %s",
+		storageDomainID, datacenterID, clusterID)
+}
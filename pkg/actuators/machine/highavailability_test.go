@@ -0,0 +1,70 @@
+//go:build unit
+
+package machine
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+)
+
+func TestHighAvailabilityVM(t *testing.T) {
+	testcases := []struct {
+		name                    string
+		ha                      *v1beta1.HighAvailability
+		expectPriority          bool
+		expectedLease           string
+		expectedResumeBehaviour ovirtsdk.VmStorageErrorResumeBehaviour
+	}{
+		{
+			name: "enabled without priority keeps the engine default",
+			ha:   &v1beta1.HighAvailability{Enabled: true},
+		},
+		{
+			name: "enabled with priority, lease and resume behaviour",
+			ha: &v1beta1.HighAvailability{
+				Enabled:              true,
+				Priority:             100,
+				LeaseStorageDomainID: "sd",
+				ResumeBehaviour:      "kill",
+			},
+			expectPriority:          true,
+			expectedLease:           "sd",
+			expectedResumeBehaviour: ovirtsdk.VMSTORAGEERRORRESUMEBEHAVIOUR_KILL,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			vm, err := highAvailabilityVM(testcase.ha)
+			if err != nil {
+				t.Fatalf("Unexpected error occurred while building the high availability settings: %v", err)
+			}
+			ha, ok := vm.HighAvailability()
+			if !ok {
+				t.Fatalf("Expected high availability settings")
+			}
+			if enabled, _ := ha.Enabled(); enabled != testcase.ha.Enabled {
+				t.Errorf("Expected high availability enabled %t, but got %t", testcase.ha.Enabled, enabled)
+			}
+			priority, ok := ha.Priority()
+			if ok != testcase.expectPriority || (ok && priority != int64(testcase.ha.Priority)) {
+				t.Errorf("Expected priority %d to be set %t, but got %d set %t",
+					testcase.ha.Priority, testcase.expectPriority, priority, ok)
+			}
+			leaseID := ""
+			if lease, ok := vm.Lease(); ok {
+				if storageDomain, ok := lease.StorageDomain(); ok {
+					leaseID, _ = storageDomain.Id()
+				}
+			}
+			if leaseID != testcase.expectedLease {
+				t.Errorf("Expected lease storage domain %q, but got %q", testcase.expectedLease, leaseID)
+			}
+			if resumeBehaviour, _ := vm.StorageErrorResumeBehaviour(); resumeBehaviour != testcase.expectedResumeBehaviour {
+				t.Errorf("Expected resume behaviour %q, but got %q", testcase.expectedResumeBehaviour, resumeBehaviour)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// vmHardware is the CPU topology and the memory of a VM.
+type vmHardware struct {
+	sockets uint
+	cores   uint
+	threads uint
+	// memory is the memory of the VM in bytes.
+	memory int64
+	// maxMemory is the memory in bytes up to which memory can be hot-plugged, 0 if unknown.
+	maxMemory int64
+}
+
+// hardwareChanges are the differences between the hardware of the spec and the one of a VM.
+type hardwareChanges struct {
+	// hotPluggable are the changes the engine applies to a running VM.
+	hotPluggable []string
+	// restartRequired are the changes which only apply after a restart of the VM.
+	restartRequired []string
+}
+
+func (c hardwareChanges) empty() bool {
+	return len(c.hotPluggable) == 0 && len(c.restartRequired) == 0
+}
+
+// desiredHardware returns the hardware of the spec, taking the values the spec doesn't define from the VM.
+// With an auto pinning policy the engine resizes the CPU topology to the host, so the CPU of the VM is kept.
+func (ms *machineScope) desiredHardware(current vmHardware) vmHardware {
+	desired := current
+	if cpu := ms.machineProviderSpec.CPU; cpu != nil && !ms.isAutoPinning() {
+		desired.sockets = uint(cpu.Sockets)
+		desired.cores = uint(cpu.Cores)
+		desired.threads = uint(cpu.Threads)
+	}
+	if ms.machineProviderSpec.MemoryMB > 0 {
+		desired.memory = int64(bytesInMB) * int64(ms.machineProviderSpec.MemoryMB)
+	}
+	return desired
+}
+
+// diffHardware returns the changes needed to turn the current hardware into the desired one.
+// The engine hot-plugs additional sockets and additional memory up to the maximum memory of the VM,
+// all other changes need a restart.
+func diffHardware(current vmHardware, desired vmHardware) hardwareChanges {
+	var changes hardwareChanges
+	if desired.sockets > current.sockets {
+		changes.hotPluggable = append(changes.hotPluggable,
+			fmt.Sprintf("sockets %d -> %d", current.sockets, desired.sockets))
+	} else if desired.sockets < current.sockets {
+		changes.restartRequired = append(changes.restartRequired,
+			fmt.Sprintf("sockets %d -> %d", current.sockets, desired.sockets))
+	}
+	if desired.cores != current.cores {
+		changes.restartRequired = append(changes.restartRequired,
+			fmt.Sprintf("cores %d -> %d", current.cores, desired.cores))
+	}
+	if desired.threads != current.threads {
+		changes.restartRequired = append(changes.restartRequired,
+			fmt.Sprintf("threads %d -> %d", current.threads, desired.threads))
+	}
+	memoryChange := fmt.Sprintf("memory %dMiB -> %dMiB", current.memory/bytesInMB, desired.memory/bytesInMB)
+	switch {
+	case desired.memory > current.memory && (current.maxMemory == 0 || desired.memory <= current.maxMemory):
+		changes.hotPluggable = append(changes.hotPluggable, memoryChange)
+	case desired.memory != current.memory:
+		changes.restartRequired = append(changes.restartRequired, memoryChange)
+	}
+	return changes
+}
+
+// reconcileHardware applies changes of the CPU sockets and the memory of the spec to the VM. The VM is updated
+// directly while it is down. Additional sockets and memory are hot-plugged into a running VM, the other changes
+// are stored as the next run configuration of the VM and reported by the RestartRequired condition.
+func (ms *machineScope) reconcileHardware(instance ovirtC.VM) error {
+	if ms.machineProviderSpec.InstanceTypeId != "" {
+		// the instance type defines the hardware of the VM
+		return nil
+	}
+	topo := instance.CPU().Topo()
+	current := vmHardware{
+		sockets: topo.Sockets(),
+		cores:   topo.Cores(),
+		threads: topo.Threads(),
+		memory:  instance.Memory(),
+	}
+	if maxMemory := instance.MemoryPolicy().Max(); maxMemory != nil {
+		current.maxMemory = *maxMemory
+	}
+	desired := ms.desiredHardware(current)
+	changes := diffHardware(current, desired)
+	if changes.empty() {
+		return ms.setRestartRequiredCondition(metav1.ConditionFalse, "HardwareApplied",
+			"CPU and memory of the VM match the spec")
+	}
+
+	switch instance.Status() {
+	case ovirtC.VMStatusDown:
+		ms.logger.Infof("updating CPU and memory of VM %s: %s", instance.Name(),
+			strings.Join(append(changes.hotPluggable, changes.restartRequired...), ", "))
+		if _, err := ms.updateVMHardware(instance.ID(), desired, false); err != nil {
+			return err
+		}
+		return ms.setRestartRequiredCondition(metav1.ConditionFalse, "HardwareApplied",
+			"CPU and memory of the VM match the spec")
+	case ovirtC.VMStatusUp:
+	default:
+		// the VM is in a transient state, it is updated once it is up or down
+		return nil
+	}
+
+	pendingRestart := changes.restartRequired
+	if len(changes.hotPluggable) > 0 {
+		// the hot-plugged values are applied on top of the current hardware, the rest waits for the restart
+		hotPlugged := current
+		hotPlugged.sockets = max(current.sockets, desired.sockets)
+		hotPlugged.memory = max(current.memory, desired.memory)
+		if current.maxMemory > 0 && hotPlugged.memory > current.maxMemory {
+			hotPlugged.memory = current.memory
+		}
+		ms.logger.Infof("hot-plugging into VM %s: %s", instance.Name(), strings.Join(changes.hotPluggable, ", "))
+		nextRunExists, err := ms.updateVMHardware(instance.ID(), hotPlugged, false)
+		if err != nil {
+			return err
+		}
+		if nextRunExists && len(pendingRestart) == 0 {
+			// the engine couldn't apply everything to the running VM
+			pendingRestart = changes.hotPluggable
+		}
+	}
+	if len(pendingRestart) == 0 {
+		return ms.setRestartRequiredCondition(metav1.ConditionFalse, "HardwareApplied",
+			"CPU and memory of the VM match the spec")
+	}
+
+	if len(changes.restartRequired) > 0 {
+		if err := ms.reconcileNextRunHardware(instance.ID(), desired); err != nil {
+			return err
+		}
+	}
+	return ms.setRestartRequiredCondition(metav1.ConditionTrue, "PendingChanges",
+		fmt.Sprintf("changes apply after a restart of the VM: %s", strings.Join(pendingRestart, ", ")))
+}
+
+// reconcileNextRunHardware stores the desired hardware as the next run configuration of the VM,
+// unless the next run configuration already has it.
+func (ms *machineScope) reconcileNextRunHardware(id ovirtC.VMID, desired vmHardware) error {
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return err
+	}
+	response, err := conn.SystemService().VmsService().VmService(string(id)).Get().NextRun(true).Send()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get next run configuration of VM %s", id)
+	}
+	if vm, ok := response.Vm(); ok {
+		nextRun := vmHardware{}
+		if cpu, ok := vm.Cpu(); ok {
+			if topology, ok := cpu.Topology(); ok {
+				sockets, _ := topology.Sockets()
+				cores, _ := topology.Cores()
+				threads, _ := topology.Threads()
+				nextRun.sockets, nextRun.cores, nextRun.threads = uint(sockets), uint(cores), uint(threads)
+			}
+		}
+		nextRun.memory, _ = vm.Memory()
+		if diffHardware(nextRun, desired).empty() {
+			return nil
+		}
+	}
+	ms.logger.Infof("storing CPU and memory of the spec as next run configuration of VM %s", id)
+	_, err = ms.updateVMHardware(id, desired, true)
+	return err
+}
+
+// updateVMHardware updates the CPU topology and the memory of the VM, as the next run configuration if nextRun
+// is set. It returns true if the VM has a next run configuration afterwards.
+// go-ovirt-client doesn't support updating the hardware of a VM, so the oVirt SDK is used.
+func (ms *machineScope) updateVMHardware(id ovirtC.VMID, hardware vmHardware, nextRun bool) (bool, error) {
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return false, err
+	}
+	vm := ovirtsdk.NewVmBuilder().
+		Cpu(ovirtsdk.NewCpuBuilder().
+			Topology(ovirtsdk.NewCpuTopologyBuilder().
+				Sockets(int64(hardware.sockets)).
+				Cores(int64(hardware.cores)).
+				Threads(int64(hardware.threads)).
+				MustBuild()).
+			MustBuild()).
+		Memory(hardware.memory).
+		MustBuild()
+	response, err := conn.SystemService().VmsService().VmService(string(id)).Update().Vm(vm).NextRun(nextRun).Send()
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to update CPU and memory of VM %s", id)
+	}
+	if updated, ok := response.Vm(); ok {
+		nextRunExists, _ := updated.NextRunConfigurationExists()
+		return nextRunExists, nil
+	}
+	return false, nil
+}
+
+// setRestartRequiredCondition sets the RestartRequired condition of the provider status.
+func (ms *machineScope) setRestartRequiredCondition(status metav1.ConditionStatus, reason string, message string) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	ms.setCondition(providerStatus, ovirtconfigv1.RestartRequiredCondition, status, reason, message)
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
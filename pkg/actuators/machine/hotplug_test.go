@@ -0,0 +1,94 @@
+//go:build unit
+
+package machine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+)
+
+func TestDiffHardware(t *testing.T) {
+	current := vmHardware{sockets: 2, cores: 2, threads: 1, memory: 8192 * bytesInMB, maxMemory: 32768 * bytesInMB}
+	testcases := []struct {
+		name     string
+		desired  vmHardware
+		expected hardwareChanges
+	}{
+		{
+			name:    "unchanged hardware",
+			desired: current,
+		},
+		{
+			name:    "additional sockets and memory are hot-pluggable",
+			desired: vmHardware{sockets: 4, cores: 2, threads: 1, memory: 16384 * bytesInMB},
+			expected: hardwareChanges{
+				hotPluggable: []string{"sockets 2 -> 4", "memory 8192MiB -> 16384MiB"},
+			},
+		},
+		{
+			name:    "fewer sockets and less memory require a restart",
+			desired: vmHardware{sockets: 1, cores: 2, threads: 1, memory: 4096 * bytesInMB},
+			expected: hardwareChanges{
+				restartRequired: []string{"sockets 2 -> 1", "memory 8192MiB -> 4096MiB"},
+			},
+		},
+		{
+			name:    "changed cores and threads require a restart",
+			desired: vmHardware{sockets: 4, cores: 4, threads: 2, memory: 8192 * bytesInMB},
+			expected: hardwareChanges{
+				hotPluggable:    []string{"sockets 2 -> 4"},
+				restartRequired: []string{"cores 2 -> 4", "threads 1 -> 2"},
+			},
+		},
+		{
+			name:    "memory beyond the maximum memory requires a restart",
+			desired: vmHardware{sockets: 2, cores: 2, threads: 1, memory: 65536 * bytesInMB},
+			expected: hardwareChanges{
+				restartRequired: []string{"memory 8192MiB -> 65536MiB"},
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			changes := diffHardware(current, testcase.desired)
+			if !reflect.DeepEqual(changes, testcase.expected) {
+				t.Errorf("Expected changes %+v, but got %+v", testcase.expected, changes)
+			}
+		})
+	}
+}
+
+func TestMachineScope_DesiredHardware(t *testing.T) {
+	current := vmHardware{sockets: 1, cores: 16, threads: 2, memory: 8192 * bytesInMB}
+	testcases := []struct {
+		name              string
+		autoPinningPolicy string
+		expected          vmHardware
+	}{
+		{
+			name:     "CPU and memory of the spec",
+			expected: vmHardware{sockets: 2, cores: 4, threads: 1, memory: 16384 * bytesInMB},
+		},
+		{
+			name:              "CPU resized by the auto pinning policy is kept",
+			autoPinningPolicy: "resize_and_pin",
+			expected:          vmHardware{sockets: 1, cores: 16, threads: 2, memory: 16384 * bytesInMB},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			ms := machineScope{machineProviderSpec: &v1beta1.OvirtMachineProviderSpec{
+				CPU:               &v1beta1.CPU{Sockets: 2, Cores: 4, Threads: 1},
+				MemoryMB:          16384,
+				AutoPinningPolicy: testcase.autoPinningPolicy,
+			}}
+			if desired := ms.desiredHardware(current); desired != testcase.expected {
+				t.Errorf("Expected hardware %+v, but got %+v", testcase.expected, desired)
+			}
+		})
+	}
+}
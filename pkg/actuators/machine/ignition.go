@@ -0,0 +1,138 @@
+package machine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/pkg/errors"
+)
+
+const (
+	networkManagerConnectionsDir = "/etc/NetworkManager/system-connections"
+	// networkManagerKeyfileMode is the file mode of the keyfiles, NetworkManager ignores keyfiles
+	// which are readable by others.
+	networkManagerKeyfileMode = 0600
+	defaultIgnitionVersion    = "3.2.0"
+)
+
+// addNetworkConfigToIgnition adds a NetworkManager keyfile for every network interface with a
+// static network configuration to the storage section of the ignition.
+func addNetworkConfigToIgnition(ignition []byte, nics []*ovirtconfigv1.NetworkInterface) ([]byte, error) {
+	config := map[string]interface{}{}
+	if len(ignition) == 0 {
+		config["ignition"] = map[string]interface{}{"version": defaultIgnitionVersion}
+	} else if err := json.Unmarshal(ignition, &config); err != nil {
+		return nil, errors.Wrap(err, "failed to parse ignition")
+	}
+
+	storage, ok := config["storage"].(map[string]interface{})
+	if !ok {
+		storage = map[string]interface{}{}
+	}
+	files, ok := storage["files"].([]interface{})
+	if !ok {
+		files = []interface{}{}
+	}
+
+	legacyIgnition := strings.HasPrefix(ignitionVersion(config), "2.")
+	for i, nic := range nics {
+		if nic.NetworkConfig == nil {
+			continue
+		}
+		connectionName := nic.Name
+		if connectionName == "" {
+			connectionName = fmt.Sprintf("nic%d", i+1)
+		}
+		keyfile := renderNetworkManagerKeyfile(connectionName, nic)
+		file := map[string]interface{}{
+			"path":      fmt.Sprintf("%s/%s.nmconnection", networkManagerConnectionsDir, connectionName),
+			"mode":      networkManagerKeyfileMode,
+			"overwrite": true,
+			"contents": map[string]interface{}{
+				"source": "data:text/plain;charset=utf-8;base64," + base64.StdEncoding.EncodeToString([]byte(keyfile)),
+			},
+		}
+		if legacyIgnition {
+			// ignition spec 2 requires the filesystem and doesn't know about overwrite
+			delete(file, "overwrite")
+			file["filesystem"] = "root"
+		}
+		files = append(files, file)
+	}
+
+	storage["files"] = files
+	config["storage"] = storage
+	return json.Marshal(config)
+}
+
+// ignitionVersion returns the spec version of the ignition config, or an empty string if it is not set.
+func ignitionVersion(config map[string]interface{}) string {
+	ignitionSection, ok := config["ignition"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	version, _ := ignitionSection["version"].(string)
+	return version
+}
+
+// renderNetworkManagerKeyfile renders the static network configuration of the network interface
+// as NetworkManager keyfile.
+func renderNetworkManagerKeyfile(connectionName string, nic *ovirtconfigv1.NetworkInterface) string {
+	networkConfig := nic.NetworkConfig
+
+	var ipv4DNS, ipv6DNS []string
+	for _, server := range networkConfig.DNSServers {
+		if ip := net.ParseIP(server); ip != nil && ip.To4() == nil {
+			ipv6DNS = append(ipv6DNS, server)
+		} else {
+			ipv4DNS = append(ipv4DNS, server)
+		}
+	}
+	// the search domains are added to the first configured address family
+	ipv4SearchDomains := networkConfig.SearchDomains
+	var ipv6SearchDomains []string
+	if networkConfig.IPv4 == nil && networkConfig.IPv6 != nil {
+		ipv4SearchDomains, ipv6SearchDomains = nil, networkConfig.SearchDomains
+	}
+
+	var b strings.Builder
+	b.WriteString("[connection]\n")
+	fmt.Fprintf(&b, "id=%s\n", connectionName)
+	b.WriteString("type=ethernet\n")
+	if nic.MACAddress == "" {
+		fmt.Fprintf(&b, "interface-name=%s\n", networkConfig.GuestInterfaceName)
+	}
+	b.WriteString("autoconnect=true\n")
+	if nic.MACAddress != "" {
+		b.WriteString("\n[ethernet]\n")
+		fmt.Fprintf(&b, "mac-address=%s\n", strings.ToUpper(nic.MACAddress))
+	}
+	b.WriteString("\n[ipv4]\n")
+	writeIPSection(&b, networkConfig.IPv4, ipv4DNS, ipv4SearchDomains)
+	b.WriteString("\n[ipv6]\n")
+	writeIPSection(&b, networkConfig.IPv6, ipv6DNS, ipv6SearchDomains)
+	return b.String()
+}
+
+func writeIPSection(b *strings.Builder, ipConfig *ovirtconfigv1.IPConfig, dnsServers []string, searchDomains []string) {
+	if ipConfig == nil {
+		b.WriteString("method=auto\n")
+	} else {
+		b.WriteString("method=manual\n")
+		address := fmt.Sprintf("%s/%d", ipConfig.Address, ipConfig.Prefix)
+		if ipConfig.Gateway != "" {
+			address += "," + ipConfig.Gateway
+		}
+		fmt.Fprintf(b, "address1=%s\n", address)
+	}
+	if len(dnsServers) > 0 {
+		fmt.Fprintf(b, "dns=%s;\n", strings.Join(dnsServers, ";"))
+	}
+	if len(searchDomains) > 0 {
+		fmt.Fprintf(b, "dns-search=%s;\n", strings.Join(searchDomains, ";"))
+	}
+}
@@ -0,0 +1,118 @@
+//go:build unit
+
+package machine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+)
+
+func TestAddNetworkConfigToIgnition(t *testing.T) {
+	testcases := []struct {
+		name             string
+		ignition         string
+		nics             []*v1beta1.NetworkInterface
+		expectedFiles    int
+		expectedPath     string
+		expectedKeyfile  []string
+		expectFilesystem bool
+	}{
+		{
+			name:     "static IPv4 configuration matched by MAC address",
+			ignition: `{"ignition":{"version":"3.2.0","config":{"merge":[{"source":"https://api-int:22623/config/worker"}]}}}`,
+			nics: []*v1beta1.NetworkInterface{
+				{
+					Name:       "nic1",
+					MACAddress: "56:6f:1a:2b:00:01",
+					NetworkConfig: &v1beta1.NetworkConfig{
+						IPv4:          &v1beta1.IPConfig{Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1"},
+						DNSServers:    []string{"10.0.0.2", "fd00::2"},
+						SearchDomains: []string{"example.com"},
+					},
+				},
+			},
+			expectedFiles: 1,
+			expectedPath:  "/etc/NetworkManager/system-connections/nic1.nmconnection",
+			expectedKeyfile: []string{
+				"mac-address=56:6F:1A:2B:00:01",
+				"[ipv4]\nmethod=manual\naddress1=10.0.0.10/24,10.0.0.1\ndns=10.0.0.2;\ndns-search=example.com;\n",
+				"[ipv6]\nmethod=auto\ndns=fd00::2;\n",
+			},
+		},
+		{
+			name:     "static IPv6 configuration matched by interface name on ignition spec 2",
+			ignition: `{"ignition":{"version":"2.2.0"},"storage":{"files":[{"path":"/etc/hostname"}]}}`,
+			nics: []*v1beta1.NetworkInterface{
+				{VNICProfileID: "profile"},
+				{
+					NetworkConfig: &v1beta1.NetworkConfig{
+						GuestInterfaceName: "ens4",
+						IPv6:               &v1beta1.IPConfig{Address: "fd00::10", Prefix: 64},
+					},
+				},
+			},
+			expectedFiles: 2,
+			expectedPath:  "/etc/NetworkManager/system-connections/nic2.nmconnection",
+			expectedKeyfile: []string{
+				"interface-name=ens4",
+				"[ipv4]\nmethod=auto\n",
+				"[ipv6]\nmethod=manual\naddress1=fd00::10/64\n",
+			},
+			expectFilesystem: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			result, err := addNetworkConfigToIgnition([]byte(testcase.ignition), testcase.nics)
+			if err != nil {
+				t.Fatalf("Unexpected error occurred adding network configuration: %v", err)
+			}
+
+			config := struct {
+				Storage struct {
+					Files []struct {
+						Path       string `json:"path"`
+						Filesystem string `json:"filesystem"`
+						Mode       int    `json:"mode"`
+						Contents   struct {
+							Source string `json:"source"`
+						} `json:"contents"`
+					} `json:"files"`
+				} `json:"storage"`
+			}{}
+			if err := json.Unmarshal(result, &config); err != nil {
+				t.Fatalf("Failed to parse resulting ignition: %v", err)
+			}
+			if len(config.Storage.Files) != testcase.expectedFiles {
+				t.Fatalf("Expected %d files, but got %d", testcase.expectedFiles, len(config.Storage.Files))
+			}
+
+			file := config.Storage.Files[len(config.Storage.Files)-1]
+			if file.Path != testcase.expectedPath {
+				t.Errorf("Expected keyfile path to be %s, but got %s", testcase.expectedPath, file.Path)
+			}
+			if file.Mode != networkManagerKeyfileMode {
+				t.Errorf("Expected keyfile mode to be %d, but got %d", networkManagerKeyfileMode, file.Mode)
+			}
+			if (file.Filesystem == "root") != testcase.expectFilesystem {
+				t.Errorf("Expected filesystem to be set (%t), but got '%s'", testcase.expectFilesystem, file.Filesystem)
+			}
+
+			encoded := strings.TrimPrefix(file.Contents.Source, "data:text/plain;charset=utf-8;base64,")
+			keyfile, err := base64.StdEncoding.DecodeString(encoded)
+			if err != nil {
+				t.Fatalf("Failed to decode keyfile: %v", err)
+			}
+			for _, expected := range testcase.expectedKeyfile {
+				if !strings.Contains(string(keyfile), expected) {
+					t.Errorf("Expected keyfile to contain %q, but got:\n%s", expected, keyfile)
+				}
+			}
+		})
+	}
+}
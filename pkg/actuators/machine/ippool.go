@@ -0,0 +1,262 @@
+package machine
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/pkg/errors"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// allocateIPPoolAddresses allocates an address for every network interface which references an IP pool
+// and sets the static network configuration of the interface to the allocated address. The IP pools are
+// recorded in the provider status, so the addresses are released even if the spec stops referencing them.
+func (ms *machineScope) allocateIPPoolAddresses() error {
+	for i, nic := range ms.machineProviderSpec.NetworkInterfaces {
+		if nic.NetworkConfig == nil || nic.NetworkConfig.IPPool == "" {
+			continue
+		}
+		if err := ms.recordIPPool(nic.NetworkConfig.IPPool); err != nil {
+			return err
+		}
+		nicName := nic.Name
+		if nicName == "" {
+			nicName = fmt.Sprintf("nic%d", i+1)
+		}
+		pool, address, err := ms.allocateAddress(nic.NetworkConfig.IPPool, ms.ipPoolOwner(nicName))
+		if err != nil {
+			return errors.Wrapf(err, "failed to allocate address for network interface %s from IP pool %s",
+				nicName, nic.NetworkConfig.IPPool)
+		}
+		ms.logger.Infof("allocated address %s for network interface %s from IP pool %s", address, nicName, pool.Name)
+
+		ipConfig := &ovirtconfigv1.IPConfig{
+			Address: address,
+			Prefix:  pool.Spec.Prefix,
+			Gateway: pool.Spec.Gateway,
+		}
+		if net.ParseIP(address).To4() != nil {
+			nic.NetworkConfig.IPv4 = ipConfig
+		} else {
+			nic.NetworkConfig.IPv6 = ipConfig
+		}
+		if len(nic.NetworkConfig.DNSServers) == 0 {
+			nic.NetworkConfig.DNSServers = pool.Spec.DNSServers
+		}
+		if len(nic.NetworkConfig.SearchDomains) == 0 {
+			nic.NetworkConfig.SearchDomains = pool.Spec.SearchDomains
+		}
+	}
+	return nil
+}
+
+// allocateAddress allocates a free address of the IP pool to the owner. If the owner already has an address
+// allocated from the pool, that address is returned.
+func (ms *machineScope) allocateAddress(poolName string, owner string) (*ovirtconfigv1.OvirtIPPool, string, error) {
+	pool := &ovirtconfigv1.OvirtIPPool{}
+	var address string
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if err := ms.client.Get(ms.Context, client.ObjectKey{Name: poolName}, pool); err != nil {
+			return err
+		}
+		for allocatedAddress, allocationOwner := range pool.Status.Allocations {
+			if allocationOwner == owner {
+				address = allocatedAddress
+				return nil
+			}
+		}
+
+		freeAddress, err := findFreeAddress(pool)
+		if err != nil {
+			return err
+		}
+		if pool.Status.Allocations == nil {
+			pool.Status.Allocations = make(map[string]string)
+		}
+		pool.Status.Allocations[freeAddress] = owner
+		if err := ms.client.Status().Update(ms.Context, pool); err != nil {
+			return err
+		}
+		address = freeAddress
+		return nil
+	})
+	return pool, address, err
+}
+
+// recordIPPool records in the provider status that addresses are allocated from the IP pool.
+func (ms *machineScope) recordIPPool(poolName string) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	for _, name := range providerStatus.IPPools {
+		if name == poolName {
+			return nil
+		}
+	}
+	providerStatus.IPPools = append(providerStatus.IPPools, poolName)
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
+
+// ipPoolNames returns the names of the IP pools referenced by the network interfaces of the spec or recorded
+// in the provider status, sorted and without duplicates.
+func (ms *machineScope) ipPoolNames() ([]string, error) {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	names := map[string]bool{}
+	for _, name := range providerStatus.IPPools {
+		names[name] = true
+	}
+	for _, nic := range ms.machineProviderSpec.NetworkInterfaces {
+		if nic != nil && nic.NetworkConfig != nil && nic.NetworkConfig.IPPool != "" {
+			names[nic.NetworkConfig.IPPool] = true
+		}
+	}
+	poolNames := make([]string, 0, len(names))
+	for name := range names {
+		poolNames = append(poolNames, name)
+	}
+	sort.Strings(poolNames)
+	return poolNames, nil
+}
+
+// releaseIPPoolAddresses releases the addresses allocated to the machine from the IP pools referenced by the
+// spec or recorded in the provider status. Machines without IP pools don't touch the IP pool API, which isn't
+// installed on every cluster; pools which don't exist have nothing to release.
+func (ms *machineScope) releaseIPPoolAddresses() error {
+	poolNames, err := ms.ipPoolNames()
+	if err != nil {
+		return err
+	}
+	ownerPrefix := ms.ipPoolOwner("")
+	for _, poolName := range poolNames {
+		poolName := poolName
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			pool := &ovirtconfigv1.OvirtIPPool{}
+			if err := ms.client.Get(ms.Context, client.ObjectKey{Name: poolName}, pool); err != nil {
+				if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+					return nil
+				}
+				return err
+			}
+			released := false
+			for address, owner := range pool.Status.Allocations {
+				if strings.HasPrefix(owner, ownerPrefix) {
+					ms.logger.Infof("releasing address %s of IP pool %s", address, poolName)
+					delete(pool.Status.Allocations, address)
+					released = true
+				}
+			}
+			if !released {
+				return nil
+			}
+			return ms.client.Status().Update(ms.Context, pool)
+		})
+		if err != nil {
+			return errors.Wrapf(err, "failed to release addresses of IP pool %s", poolName)
+		}
+	}
+	return nil
+}
+
+// ipPoolOwner returns the owner of an IP pool allocation for the network interface of the machine.
+func (ms *machineScope) ipPoolOwner(nicName string) string {
+	return fmt.Sprintf("%s/%s/%s", ms.machine.Namespace, ms.machine.Name, nicName)
+}
+
+// findFreeAddress returns the first address of the pool which is neither allocated nor the gateway.
+func findFreeAddress(pool *ovirtconfigv1.OvirtIPPool) (string, error) {
+	for _, entry := range pool.Spec.Addresses {
+		first, last, err := parseIPPoolEntry(entry)
+		if err != nil {
+			return "", errors.Wrapf(err, "invalid address %s in IP pool %s", entry, pool.Name)
+		}
+		for ip := first; bytes.Compare(ip, last) <= 0; ip = nextIP(ip) {
+			address := ip.String()
+			if _, allocated := pool.Status.Allocations[address]; allocated || address == pool.Spec.Gateway {
+				continue
+			}
+			return address, nil
+		}
+	}
+	return "", fmt.Errorf("IP pool %s has no free addresses", pool.Name)
+}
+
+// parseIPPoolEntry returns the first and the last address of an IP pool entry, which is either
+// a single address, a range of addresses or a CIDR. The network and broadcast addresses of IPv4
+// CIDRs are excluded.
+func parseIPPoolEntry(entry string) (net.IP, net.IP, error) {
+	if strings.Contains(entry, "/") {
+		ip, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, nil, err
+		}
+		first := ipNet.IP.To16()
+		last := make(net.IP, len(ipNet.IP))
+		for i := range ipNet.IP {
+			last[i] = ipNet.IP[i] | ^ipNet.Mask[i]
+		}
+		last = last.To16()
+		if ones, bits := ipNet.Mask.Size(); ip.To4() != nil && bits-ones > 1 {
+			first = nextIP(first)
+			last = previousIP(last)
+		}
+		return first, last, nil
+	}
+
+	bounds := strings.SplitN(entry, "-", 2)
+	first := net.ParseIP(strings.TrimSpace(bounds[0]))
+	if first == nil {
+		return nil, nil, fmt.Errorf("%s is not a valid address", bounds[0])
+	}
+	last := first
+	if len(bounds) == 2 {
+		last = net.ParseIP(strings.TrimSpace(bounds[1]))
+		if last == nil {
+			return nil, nil, fmt.Errorf("%s is not a valid address", bounds[1])
+		}
+	}
+	if (first.To4() == nil) != (last.To4() == nil) {
+		return nil, nil, fmt.Errorf("range %s mixes IPv4 and IPv6 addresses", entry)
+	}
+	return first.To16(), last.To16(), nil
+}
+
+func nextIP(ip net.IP) net.IP {
+	next := make(net.IP, len(ip))
+	copy(next, ip)
+	for i := len(next) - 1; i >= 0; i-- {
+		next[i]++
+		if next[i] != 0 {
+			return next
+		}
+	}
+	// the address overflowed, return an address which is bigger than every other address
+	return append(net.IP{1}, next...)
+}
+
+func previousIP(ip net.IP) net.IP {
+	previous := make(net.IP, len(ip))
+	copy(previous, ip)
+	for i := len(previous) - 1; i >= 0; i-- {
+		previous[i]--
+		if previous[i] != 0xff {
+			break
+		}
+	}
+	return previous
+}
@@ -0,0 +1,127 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFindFreeAddress(t *testing.T) {
+	testcases := []struct {
+		name            string
+		spec            v1beta1.OvirtIPPoolSpec
+		allocations     map[string]string
+		expectedAddress string
+		expectError     bool
+	}{
+		{
+			name:            "first address of a range is allocated",
+			spec:            v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.10-10.0.0.20"}},
+			expectedAddress: "10.0.0.10",
+		},
+		{
+			name:            "allocated addresses and the gateway are skipped",
+			spec:            v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.1-10.0.0.3"}, Gateway: "10.0.0.1"},
+			allocations:     map[string]string{"10.0.0.2": "default/worker-0/nic1"},
+			expectedAddress: "10.0.0.3",
+		},
+		{
+			name:            "network address of a CIDR is skipped",
+			spec:            v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.0/30"}},
+			expectedAddress: "10.0.0.1",
+		},
+		{
+			name:            "next entry is used when an entry is exhausted",
+			spec:            v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.10", "10.0.1.0/30"}},
+			allocations:     map[string]string{"10.0.0.10": "default/worker-0/nic1", "10.0.1.1": "default/worker-1/nic1"},
+			expectedAddress: "10.0.1.2",
+		},
+		{
+			name:            "IPv6 range crossing a byte boundary",
+			spec:            v1beta1.OvirtIPPoolSpec{Addresses: []string{"fd00::ff-fd00::1ff"}},
+			allocations:     map[string]string{"fd00::ff": "default/worker-0/nic1"},
+			expectedAddress: "fd00::100",
+		},
+		{
+			name:        "exhausted pool fails",
+			spec:        v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.0/31"}},
+			allocations: map[string]string{"10.0.0.0": "default/worker-0/nic1", "10.0.0.1": "default/worker-1/nic1"},
+			expectError: true,
+		},
+		{
+			name:        "invalid address fails",
+			spec:        v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.300"}},
+			expectError: true,
+		},
+		{
+			name:        "range mixing address families fails",
+			spec:        v1beta1.OvirtIPPoolSpec{Addresses: []string{"10.0.0.1-fd00::1"}},
+			expectError: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			pool := &v1beta1.OvirtIPPool{
+				Spec:   testcase.spec,
+				Status: v1beta1.OvirtIPPoolStatus{Allocations: testcase.allocations},
+			}
+			pool.Name = "pool"
+			address, err := findFreeAddress(pool)
+			if (err != nil) != testcase.expectError {
+				t.Fatalf("Expected error (%t), but got '%v'", testcase.expectError, err)
+			}
+			if address != testcase.expectedAddress {
+				t.Errorf("Expected address %s, but got %s", testcase.expectedAddress, address)
+			}
+		})
+	}
+}
+
+func TestMachineScope_IPPoolNames(t *testing.T) {
+	providerStatus, err := v1beta1.RawExtensionFromProviderStatus(&v1beta1.OvirtMachineProviderStatus{
+		IPPools: []string{"removed-from-spec", "workers"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build provider status: %v", err)
+	}
+	ms := machineScope{
+		machineProviderSpec: &v1beta1.OvirtMachineProviderSpec{
+			NetworkInterfaces: []*v1beta1.NetworkInterface{
+				{NetworkConfig: &v1beta1.NetworkConfig{IPPool: "workers"}},
+				{NetworkConfig: &v1beta1.NetworkConfig{IPPool: "storage"}},
+				{},
+			},
+		},
+		machine: &machinev1.Machine{Status: machinev1.MachineStatus{ProviderStatus: providerStatus}},
+	}
+
+	names, err := ms.ipPoolNames()
+	if err != nil {
+		t.Fatalf("Unexpected error occurred while collecting the IP pools: %v", err)
+	}
+	expected := []string{"removed-from-spec", "storage", "workers"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected IP pools %v, but got %v", expected, names)
+	}
+}
+
+func TestMachineScope_ReleaseIPPoolAddressesWithoutIPPools(t *testing.T) {
+	// the machine scope has no Kubernetes client, releasing must not touch the IP pool API
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		machineProviderSpec: &v1beta1.OvirtMachineProviderSpec{NetworkInterfaces: []*v1beta1.NetworkInterface{{}}},
+		machine:             &machinev1.Machine{ObjectMeta: v1.ObjectMeta{Namespace: "default", Name: "worker-0"}},
+	}
+	if err := ms.releaseIPPoolAddresses(); err != nil {
+		t.Errorf("Unexpected error occurred while releasing the IP pool addresses: %v", err)
+	}
+}
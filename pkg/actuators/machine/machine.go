@@ -12,9 +12,13 @@ import (
 	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
 	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
 	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtsdk "github.com/ovirt/go-ovirt"
 	ovirtC "github.com/ovirt/go-ovirt-client/v2"
 	"github.com/pkg/errors"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -24,6 +28,9 @@ const (
 	// GlobalInfrastuctureName default name for infrastructure object
 	globalInfrastuctureName = "cluster"
 	bytesInMB               = 1048576
+	bytesInGB               = 1073741824
+
+	networkInterfacesModeAppend = "append"
 )
 
 type machineScope struct {
@@ -31,7 +38,9 @@ type machineScope struct {
 	logger      *ovirt.KLogr
 	ovirtClient ovirtC.Client
 	client      client.Client
-	machine     *machinev1.Machine
+	// eventRecorder records events on the machine, it may be nil
+	eventRecorder record.EventRecorder
+	machine       *machinev1.Machine
 	// originalMachineToBePatched contains a patch copy of the machine when the machine scope was created
 	// it is used by k8sclient to understand the diff and patch the machine object
 	originalMachineToBePatched client.Patch
@@ -42,6 +51,7 @@ func newMachineScope(
 	ctx context.Context,
 	ovirtClient ovirtC.Client,
 	c client.Client,
+	eventRecorder record.EventRecorder,
 	machine *machinev1.Machine,
 	providerSpec *ovirtconfigv1.OvirtMachineProviderSpec) *machineScope {
 
@@ -50,24 +60,27 @@ func newMachineScope(
 		logger:                     ovirt.NewKLogr("machine-scope").WithVInfo(0),
 		ovirtClient:                ovirtClient,
 		client:                     c,
+		eventRecorder:              eventRecorder,
 		machine:                    machine,
 		originalMachineToBePatched: client.MergeFrom(machine.DeepCopy()),
 		machineProviderSpec:        providerSpec,
 	}
 }
 
-// create creates an oVirt VM from the machine object if it does not exists.
+// create starts creating an oVirt VM from the machine object if it does not exists. It only starts cloning
+// the template, the VM is configured and started by the following creation phases in reconcileCreation.
 func (ms *machineScope) create() error {
 
-	vms, err := ms.ovirtClient.GetVMByName(ms.machine.Name, ovirtC.ContextStrategy(ms.Context))
+	vms, err := ms.getVM()
 	clusterId := ms.machineProviderSpec.ClusterId
 
 	if err != nil {
-		if !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
-			return errors.Wrap(err, "error finding VM by name")
+		if !isVMNotFound(err) {
+			return err
 		}
 	}
 	if vms != nil {
+		// getVM verified that a VM found by name belongs to the machine
 		ms.logger.Infof("Skipped creating a VM that already exists.")
 		return nil
 	}
@@ -77,178 +90,412 @@ func (ms *machineScope) create() error {
 	if err != nil {
 		return errors.Wrap(err, "error getting VM ignition")
 	}
+	if err := ms.allocateIPPoolAddresses(); err != nil {
+		return err
+	}
+	if ms.hasNetworkConfig() {
+		ignition, err = addNetworkConfigToIgnition(ignition, ms.machineProviderSpec.NetworkInterfaces)
+		if err != nil {
+			return errors.Wrap(err, "error adding network configuration to VM ignition")
+		}
+	}
 	// CREATE VM from a template
-	templateName := ms.machineProviderSpec.TemplateName
-	template, err := ms.ovirtClient.GetTemplateByName(templateName, ovirtC.ContextStrategy(ms.Context))
+	templateID, err := ms.resolveTemplateID()
 	if err != nil {
-		return errors.Wrapf(err, "error finding template name %s.", templateName)
+		return err
 	}
 
-	optionalVMParams, err := ms.buildOptionalVMParameters(string(ignition), template.ID())
+	optionalVMParams, err := ms.buildOptionalVMParameters(string(ignition), templateID)
 	if err != nil {
 		return errors.Wrapf(err, "error building parameters for VM creation")
 	}
 
 	instance, err := ms.ovirtClient.CreateVM(ovirtC.ClusterID(clusterId),
-		template.ID(),
+		templateID,
 		ms.machine.Name,
 		optionalVMParams, ovirtC.ContextStrategy(ms.Context))
 
 	if err != nil {
 		return errors.Wrap(err, "error creating Ovirt instance")
 	}
+	ms.logger.Infof("started cloning VM %s from template %s", instance.ID(), templateID)
+
+	// the VM is looked up by its ID from now on
+	if err := ms.setInstanceID(string(instance.ID())); err != nil {
+		return err
+	}
+	return ms.setCreationPhase(creationPhaseCloning)
+}
+
+// createNIC creates a network interface on the VM according to the machine spec.
+// The go-ovirt-client doesn't support the MAC address, the interface model and the linked
+// and plugged state of the interface, so the oVirt SDK is used when any of them is set.
+func (ms *machineScope) createNIC(instance ovirtC.VM, name string, nic *ovirtconfigv1.NetworkInterface) error {
+	if nic.MACAddress == "" && nic.InterfaceModel == "" && nic.Linked == nil && nic.Plugged == nil {
+		_, err := instance.CreateNIC(name, ovirtC.VNICProfileID(nic.VNICProfileID), ovirtC.CreateNICParams())
+		return err
+	}
 
-	// Wait till ready
-	_, err = ms.ovirtClient.WaitForVMStatus(instance.ID(), ovirtC.VMStatusDown, ovirtC.ContextStrategy(ms.Context))
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
 	if err != nil {
-		return errors.Wrap(err, "error creating oVirt VM")
+		return err
+	}
+	nicBuilder := ovirtsdk.NewNicBuilder().
+		Name(name).
+		VnicProfile(ovirtsdk.NewVnicProfileBuilder().Id(nic.VNICProfileID).MustBuild())
+	if nic.MACAddress != "" {
+		nicBuilder.Mac(ovirtsdk.NewMacBuilder().Address(nic.MACAddress).MustBuild())
+	}
+	if nic.InterfaceModel != "" {
+		nicBuilder.Interface(ovirtsdk.NicInterface(nic.InterfaceModel))
+	}
+	if nic.Linked != nil {
+		nicBuilder.Linked(*nic.Linked)
+	}
+	if nic.Plugged != nil {
+		nicBuilder.Plugged(*nic.Plugged)
 	}
+	sdkNIC, err := nicBuilder.Build()
+	if err != nil {
+		return errors.Wrap(err, "failed to build NIC")
+	}
+	_, err = conn.SystemService().VmsService().VmService(string(instance.ID())).NicsService().Add().Nic(sdkNIC).Send()
+	return err
+}
 
-	// apply high_performance rules
-	// see: https://access.redhat.com/documentation/en-us/red_hat_virtualization/4.4/html-single/virtual_machine_management_guide/index?extIdCarryOver=true&sc_cid=701f2000001Css5AAC#Automatic_High_Performance_Configuration_Settings
-	if ms.machineProviderSpec.VMType == string(ovirtC.VMTypeHighPerformance) {
-		graphicsConsoles, err := instance.ListGraphicsConsoles()
-		if err != nil {
-			return errors.Wrapf(err, "failed to list graphics consoles")
-		}
-		for _, graphicsConsole := range graphicsConsoles {
-			err := graphicsConsole.Remove()
-			if err != nil {
-				return errors.Wrapf(err, "failed to remove graphics console '%s' from VM '%s'",
-					graphicsConsole.ID(), graphicsConsole.VMID())
-			}
-		}
+// reconcileAdditionalDisks creates the data disks defined in the machine spec and attaches them to the VM
+// as non-bootable disks. The disks are created without waiting for them, it returns true once all disks
+// are created and attached. The IDs of the created disks are recorded in the provider status, disks are never
+// looked up by their alias outside of the VM, as disks of other machines may share it.
+func (ms *machineScope) reconcileAdditionalDisks(instance ovirtC.VM) (bool, error) {
+	if len(ms.machineProviderSpec.AdditionalDisks) == 0 {
+		return true, nil
+	}
+	diskAttachments, err := instance.ListDiskAttachments(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return false, errors.Wrapf(err, "failed to list disk attachments for VM %s.", instance.ID())
+	}
+	attachedDisks := make(map[ovirtC.DiskID]bool, len(diskAttachments))
+	for _, diskAttachment := range diskAttachments {
+		attachedDisks[diskAttachment.DiskID()] = true
+	}
+	diskIDs, err := ms.additionalDiskIDs(diskAttachments)
+	if err != nil {
+		return false, err
 	}
 
-	var bootableDiskAttachment ovirtC.DiskAttachment
-	newDiskSize := uint64(ms.machineProviderSpec.OSDisk.SizeGB * int64(math.Pow(2, 30)))
-	// Handle OS disk extension
-	if ms.machineProviderSpec.OSDisk != nil {
-		diskAttachments, err := instance.ListDiskAttachments()
-		if err != nil {
-			return errors.Wrapf(err, "failed to list disk attachments for VM %s.", instance.ID())
-		}
-		for _, diskAttachment := range diskAttachments {
-			if diskAttachment.Bootable() {
-				bootableDiskAttachment = diskAttachment
+	ready := true
+	for i, additionalDisk := range ms.machineProviderSpec.AdditionalDisks {
+		alias := ms.additionalDiskAlias(i)
+		if diskID := diskIDs[i]; diskID != "" {
+			if attachedDisks[diskID] {
+				continue
 			}
+			disk, err := ms.ovirtClient.GetDisk(diskID, ovirtC.ContextStrategy(ms.Context))
+			if err != nil && !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+				return false, errors.Wrapf(err, "failed to get disk %s", diskID)
+			}
+			if err == nil {
+				if disk.Status() != ovirtC.DiskStatusOK {
+					ms.logger.Infof("waiting for disk %s to become OK...", alias)
+					ready = false
+					continue
+				}
+				diskInterface := ovirtC.DiskInterfaceVirtIOSCSI
+				if additionalDisk.Interface != "" {
+					diskInterface = ovirtC.DiskInterface(additionalDisk.Interface)
+				}
+				_, err = instance.AttachDisk(
+					disk.ID(),
+					diskInterface,
+					ovirtC.CreateDiskAttachmentParams().MustWithBootable(false).MustWithActive(true),
+					ovirtC.ContextStrategy(ms.Context))
+				if err != nil {
+					return false, errors.Wrapf(err, "failed to attach disk %s", disk.ID())
+				}
+				continue
+			}
+			// the disk was removed before it was attached, it is created again
+			ms.logger.Infof("disk %s of additional disk %s doesn't exist anymore", diskID, alias)
 		}
 
-		if bootableDiskAttachment == nil {
-			return errors.Wrapf(err, "VM %s(%s) doesn't have a bootable disk", instance.Name(), instance.ID())
+		storageDomainID := ovirtC.StorageDomainID(additionalDisk.StorageDomainId)
+		if storageDomainID == "" {
+			defaultStorageDomainID, err := ms.bootableDiskStorageDomainID(instance)
+			if err != nil {
+				return false, err
+			}
+			storageDomainID = defaultStorageDomainID
 		}
 
-		disk, err := ms.ovirtClient.GetDisk(bootableDiskAttachment.DiskID(), ovirtC.ContextStrategy(ms.Context))
-		if err != nil {
-			return err
+		format := ovirtC.ImageFormatCow
+		if additionalDisk.Format != "" {
+			format = ovirtC.ImageFormat(additionalDisk.Format)
+		} else if ms.machineProviderSpec.Format != "" {
+			format = ovirtC.ImageFormat(ms.machineProviderSpec.Format)
 		}
 
-		if newDiskSize > disk.ProvisionedSize() {
-			updatedDisk, err := disk.Update(ovirtC.UpdateDiskParams().MustWithProvisionedSize(newDiskSize))
-			if err != nil {
-				return errors.Wrapf(err, "failed to extend disk %s", disk.ID())
-			}
-			ms.logger.Infof("waiting for disk to become OK...")
-			updatedDisk, err = updatedDisk.WaitForOK()
-			if err != nil {
-				return err
-			}
+		sparse := true
+		if additionalDisk.Sparse != nil {
+			sparse = *additionalDisk.Sparse
+		} else if ms.machineProviderSpec.Sparse != nil {
+			sparse = *ms.machineProviderSpec.Sparse
 		}
-	}
 
-	// handleNics reattachment
-	if ms.machineProviderSpec.NetworkInterfaces != nil && len(ms.machineProviderSpec.NetworkInterfaces) > 0 {
-		nics, err := instance.ListNICs()
+		size := uint64(additionalDisk.SizeGB * int64(math.Pow(2, 30)))
+		ms.logger.Infof("creating additional disk %s with size %d on storage domain %s", alias, size, storageDomainID)
+		diskCreation, err := ms.ovirtClient.StartCreateDisk(
+			storageDomainID,
+			format,
+			size,
+			ovirtC.CreateDiskParams().MustWithAlias(alias).MustWithSparse(sparse),
+			ovirtC.ContextStrategy(ms.Context))
 		if err != nil {
-			return errors.Wrapf(err, "failed to list NICs on VM %s", instance.ID())
+			return false, errors.Wrapf(err, "failed to create disk %s", alias)
 		}
-
-		//remove all the nics from the VM instance
-		for _, nic := range nics {
-			if err := nic.Remove(); err != nil {
-				return errors.Wrapf(err, "failed to remove NIC %s", nic.ID())
-			}
+		diskIDs[i] = diskCreation.Disk().ID()
+		if err := ms.setAdditionalDiskIDs(diskIDs); err != nil {
+			return false, err
 		}
+		ready = false
+	}
+	return ready, nil
+}
 
-		//re-create NICs According to the machinespec
-		for i, nic := range ms.machineProviderSpec.NetworkInterfaces {
-			_, err := instance.CreateNIC(fmt.Sprintf("nic%d", i+1), ovirtC.VNICProfileID(nic.VNICProfileID), ovirtC.CreateNICParams())
-
-			if err != nil {
-				return err
-			}
+// additionalDiskIDs returns the IDs of the disks of the additional disks, in the order of the additional disks.
+// The IDs are taken from the provider status, disks attached to the VM before the IDs were recorded are found
+// by their alias. The ID of a disk which wasn't created yet is empty.
+func (ms *machineScope) additionalDiskIDs(diskAttachments []ovirtC.DiskAttachment) ([]ovirtC.DiskID, error) {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return nil, errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	diskIDs := make([]ovirtC.DiskID, len(ms.machineProviderSpec.AdditionalDisks))
+	missing := false
+	for i := range diskIDs {
+		if i < len(providerStatus.AdditionalDiskIDs) {
+			diskIDs[i] = ovirtC.DiskID(providerStatus.AdditionalDiskIDs[i])
 		}
+		missing = missing || diskIDs[i] == ""
+	}
+	if !missing {
+		return diskIDs, nil
 	}
 
-	if ms.isAutoPinning() {
-		err = ms.ovirtClient.AutoOptimizeVMCPUPinningSettings(instance.ID(), true, ovirtC.ContextStrategy(ms.Context))
+	attachedByAlias := make(map[string]ovirtC.DiskID, len(diskAttachments))
+	for _, diskAttachment := range diskAttachments {
+		if diskAttachment.Bootable() {
+			continue
+		}
+		disk, err := ms.ovirtClient.GetDisk(diskAttachment.DiskID(), ovirtC.ContextStrategy(ms.Context))
 		if err != nil {
-			return err
+			return nil, errors.Wrapf(err, "failed to get disk %s", diskAttachment.DiskID())
+		}
+		attachedByAlias[disk.Alias()] = disk.ID()
+	}
+	for i := range diskIDs {
+		if diskIDs[i] == "" {
+			diskIDs[i] = attachedByAlias[ms.additionalDiskAlias(i)]
 		}
 	}
+	return diskIDs, nil
+}
 
-	err = ms.ovirtClient.AddTagToVMByName(instance.ID(), ms.machine.Labels["machine.openshift.io/cluster-api-cluster"], ovirtC.ContextStrategy(ms.Context))
+// removeUnattachedAdditionalDisks removes the recorded disks of the additional disks which aren't attached to the
+// VM. They were created but not attached yet, so they aren't removed together with the VM. vm is nil if the VM
+// doesn't exist anymore.
+func (ms *machineScope) removeUnattachedAdditionalDisks(vm ovirtC.VM) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
 	if err != nil {
-		return err
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
 	}
-
-	for _, agName := range ms.machineProviderSpec.AffinityGroupsNames {
-		ag, err := ms.ovirtClient.GetAffinityGroupByName(ovirtC.ClusterID(clusterId), agName, ovirtC.ContextStrategy(ms.Context))
+	if len(providerStatus.AdditionalDiskIDs) == 0 {
+		return nil
+	}
+	attachedDisks := map[ovirtC.DiskID]bool{}
+	if vm != nil {
+		diskAttachments, err := vm.ListDiskAttachments(ovirtC.ContextStrategy(ms.Context))
 		if err != nil {
-			return err
+			return errors.Wrapf(err, "failed to list disk attachments for VM %s.", vm.ID())
+		}
+		for _, diskAttachment := range diskAttachments {
+			attachedDisks[diskAttachment.DiskID()] = true
+		}
+	}
+	for _, id := range providerStatus.AdditionalDiskIDs {
+		diskID := ovirtC.DiskID(id)
+		if diskID == "" || attachedDisks[diskID] {
+			continue
 		}
-		err = ag.AddVM(instance.ID())
+		err := ms.ovirtClient.RemoveDisk(diskID, ovirtC.ContextStrategy(ms.Context))
 		if err != nil {
-			return err
+			if ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+				continue
+			}
+			return errors.Wrapf(err, "failed to remove unattached disk %s", diskID)
 		}
+		ms.logger.Infof("removed unattached additional disk %s of machine %s", diskID, ms.machine.Name)
 	}
+	return nil
+}
 
-	// Start the VM
-	err = ms.ovirtClient.StartVM(instance.ID(), ovirtC.ContextStrategy(ms.Context))
+// setAdditionalDiskIDs records the IDs of the disks of the additional disks in the provider status.
+func (ms *machineScope) setAdditionalDiskIDs(diskIDs []ovirtC.DiskID) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
 	if err != nil {
-		return errors.Wrap(err, "error running oVirt VM")
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
 	}
-
-	// Wait till running
-	_, err = ms.ovirtClient.WaitForVMStatus(instance.ID(), ovirtC.VMStatusUp, ovirtC.ContextStrategy(ms.Context))
+	providerStatus.AdditionalDiskIDs = make([]string, len(diskIDs))
+	for i, diskID := range diskIDs {
+		providerStatus.AdditionalDiskIDs[i] = string(diskID)
+	}
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
 	if err != nil {
-		return errors.Wrap(err, "error waiting for oVirt VM to be UP")
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
 	}
+	ms.machine.Status.ProviderStatus = rawExtension
 	return nil
 }
 
+// additionalDiskAlias returns the alias of the additional disk with the given index.
+func (ms *machineScope) additionalDiskAlias(index int) string {
+	return fmt.Sprintf("%s_data%d", ms.machine.Name, index+1)
+}
+
+// bootableDiskStorageDomainID returns the ID of the storage domain the bootable disk of the VM is placed on.
+func (ms *machineScope) bootableDiskStorageDomainID(instance ovirtC.VM) (ovirtC.StorageDomainID, error) {
+	diskAttachments, err := instance.ListDiskAttachments(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to list disk attachments for VM %s.", instance.ID())
+	}
+	for _, diskAttachment := range diskAttachments {
+		if !diskAttachment.Bootable() {
+			continue
+		}
+		disk, err := ms.ovirtClient.GetDisk(diskAttachment.DiskID(), ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return "", err
+		}
+		storageDomainIDs := disk.StorageDomainIDs()
+		if len(storageDomainIDs) == 0 {
+			return "", fmt.Errorf("bootable disk %s of VM %s has no storage domain", disk.ID(), instance.ID())
+		}
+		return storageDomainIDs[0], nil
+	}
+	return "", fmt.Errorf("VM %s(%s) doesn't have a bootable disk", instance.Name(), instance.ID())
+}
+
 // exists returns true if machine exists.
 func (ms *machineScope) exists() (bool, error) {
-	_, err := ms.ovirtClient.GetVMByName(ms.machine.Name, ovirtC.ContextStrategy(ms.Context))
+	_, err := ms.getVM()
 	if err != nil {
-		if ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+		var notOwnedError *vmNotOwnedError
+		if isVMNotFound(err) || errors.As(err, &notOwnedError) {
+			// a VM of another cluster with the name of the machine is reported when the machine is created
 			return false, nil
 		}
-		return false, errors.Wrap(err, "error finding VM by name")
+		return false, err
 	}
 	return true, nil
 }
 
-// delete deletes the VM which corresponds with the machine object from the oVirt engine
-func (ms *machineScope) delete() error {
+// getVM returns the VM of the machine. The VM is looked up by the ID from the provider ID or the provider status,
+// the name of the machine is only used while the VM is created and its ID is not known yet. A VM found by name
+// is only returned if it belongs to the cluster of the machine, see verifyOwnership.
+func (ms *machineScope) getVM() (ovirtC.VM, error) {
+	id, err := ms.vmID()
+	if err != nil {
+		return nil, err
+	}
+	if id != "" {
+		vm, err := ms.ovirtClient.GetVM(id, ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return nil, errors.Wrapf(err, "error finding VM by ID %s", id)
+		}
+		return vm, nil
+	}
 	vm, err := ms.ovirtClient.GetVMByName(ms.machine.Name, ovirtC.ContextStrategy(ms.Context))
 	if err != nil {
-		if ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
-			return nil
+		return nil, errors.Wrap(err, "error finding VM by name")
+	}
+	if err := ms.verifyOwnership(vm); err != nil {
+		return nil, err
+	}
+	return vm, nil
+}
+
+// isVMNotFound returns true if the error of getVM means that the VM doesn't exist. ovirtC.HasErrorCode can't be
+// used on the errors of getVM, it panics on errors which aren't engine errors, like the errors of verifyOwnership.
+func isVMNotFound(err error) bool {
+	var engineErr ovirtC.EngineError
+	return errors.As(err, &engineErr) && engineErr.HasCode(ovirtC.ENotFound)
+}
+
+// vmID returns the ID of the VM of the machine, or an empty ID if it is not known yet.
+func (ms *machineScope) vmID() (ovirtC.VMID, error) {
+	if ms.machine.Spec.ProviderID != nil {
+		if id := utils.VMIDFromProviderID(*ms.machine.Spec.ProviderID); id != "" {
+			return ovirtC.VMID(id), nil
 		}
-		return errors.Wrap(err, "error finding VM by name")
 	}
-	if err := vm.Stop(true, ovirtC.ContextStrategy(ms.Context)); err != nil {
-		return err
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return "", errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
 	}
-	if _, err := vm.WaitForStatus(ovirtC.VMStatusDown, ovirtC.ContextStrategy(ms.Context)); err != nil {
-		return err
+	if providerStatus.InstanceID != nil {
+		return ovirtC.VMID(*providerStatus.InstanceID), nil
+	}
+	return "", nil
+}
+
+func (ms *machineScope) setInstanceID(id string) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	providerStatus.InstanceID = &id
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
+
+// delete deletes the VM which corresponds with the machine object from the oVirt engine.
+// The disks which don't belong to the VM are detached and preserved, their names are returned.
+func (ms *machineScope) delete() ([]string, error) {
+	vm, err := ms.getVM()
+	if err != nil {
+		if isVMNotFound(err) {
+			if err := ms.removeUnattachedAdditionalDisks(nil); err != nil {
+				return nil, err
+			}
+			return nil, ms.releaseIPPoolAddresses()
+		}
+		return nil, err
+	}
+	if err := ms.verifyOwnership(vm); err != nil {
+		return nil, err
+	}
+	if err := ms.stop(vm); err != nil {
+		return nil, err
+	}
+	if err := ms.removeUnattachedAdditionalDisks(vm); err != nil {
+		return nil, err
+	}
+	preservedDisks, err := ms.detachForeignDisks(vm)
+	if err != nil {
+		return preservedDisks, errors.Wrap(err, "error detaching foreign disks")
+	}
+	if err := ms.releaseSpreadAffinityGroup(vm); err != nil {
+		return preservedDisks, err
 	}
 	if err := vm.Remove(ovirtC.ContextStrategy(ms.Context)); err != nil && !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
-		return err
+		return preservedDisks, err
 	}
 
-	return nil
+	return preservedDisks, ms.releaseIPPoolAddresses()
 }
 
 // returns the ignition from the userData secret
@@ -275,10 +522,9 @@ func (ms *machineScope) getIgnition() ([]byte, error) {
 }
 
 func (ms *machineScope) reconcileMachine(ctx context.Context) error {
-	instance, err := ms.ovirtClient.GetVMByName(ms.machine.Name, ovirtC.ContextStrategy(ms.Context))
-
+	instance, err := ms.getVM()
 	if err != nil {
-		return errors.Wrap(err, "error finding VM by name")
+		return err
 	}
 
 	id := instance.ID()
@@ -286,14 +532,45 @@ func (ms *machineScope) reconcileMachine(ctx context.Context) error {
 	name := instance.Name()
 	ms.reconcileMachineProviderID(string(id))
 	ms.reconcileMachineAnnotations(string(status), string(id))
-	err = ms.reconcileMachineNetwork(ctx, status, name, string(id))
+	created, err := ms.isCreated()
 	if err != nil {
-		return errors.Wrap(err, "error reconciling machine network")
+		return err
 	}
-	err = ms.reconcileMachineProviderStatus(string(status), (*string)(&id))
+	// the VM passes through transient states during its creation, the network and the hardware are reconciled
+	// once it is created
+	var networkErr, hardwareErr, osDiskErr, tagsErr, commentErr, watchdogErr error
+	if created {
+		networkErr = ms.reconcileMachineNetwork(ctx, status, name, string(id))
+		hardwareErr = ms.reconcileHardware(instance)
+		osDiskErr = ms.reconcileOSDiskSize(instance)
+		tagsErr = ms.reconcileVMTags(instance)
+		commentErr = ms.reconcileVMComment(instance)
+		watchdogErr = ms.reconcileWatchdog(id)
+	}
+	// the provider status is reconciled even if the network is not, so it shows why the machine is not ready
+	err = ms.reconcileMachineProviderStatus(instance, networkErr == nil && hardwareErr == nil &&
+		osDiskErr == nil && tagsErr == nil && commentErr == nil && watchdogErr == nil)
 	if err != nil {
 		return errors.Wrap(err, "error reconciling machine provider status")
 	}
+	if networkErr != nil {
+		return errors.Wrap(networkErr, "error reconciling machine network")
+	}
+	if hardwareErr != nil {
+		return errors.Wrap(hardwareErr, "error reconciling machine hardware")
+	}
+	if osDiskErr != nil {
+		return errors.Wrap(osDiskErr, "error reconciling OS disk size")
+	}
+	if tagsErr != nil {
+		return errors.Wrap(tagsErr, "error reconciling VM tags")
+	}
+	if commentErr != nil {
+		return errors.Wrap(commentErr, "error reconciling VM comment")
+	}
+	if watchdogErr != nil {
+		return errors.Wrap(watchdogErr, "error reconciling VM watchdog")
+	}
 	return nil
 }
 
@@ -404,13 +681,54 @@ func (ms *machineScope) findUsableInternalAddress(ctx context.Context, vmID stri
 	return "", errors.Wrapf(err, "failed to find usable address for VM %s ", vmID)
 }
 
-func (ms *machineScope) reconcileMachineProviderStatus(status string, id *string) error {
+// reconcileMachineProviderStatus fills the provider status with the IDs of the resources of the VM and the
+// conditions of the VM. The time of the last successful reconcile is only updated if the reconcile succeeded.
+func (ms *machineScope) reconcileMachineProviderStatus(instance ovirtC.VM, succeeded bool) error {
 	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
 	if err != nil {
 		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
 	}
+	status := string(instance.Status())
+	id := string(instance.ID())
 	providerStatus.InstanceState = &status
-	providerStatus.InstanceID = id
+	providerStatus.InstanceID = &id
+	providerStatus.TemplateID = string(instance.TemplateID())
+	providerStatus.ClusterID = string(instance.ClusterID())
+	providerStatus.HostID = ""
+	if hostID := instance.HostID(); hostID != nil {
+		providerStatus.HostID = string(*hostID)
+	}
+
+	diskAttachments, err := instance.ListDiskAttachments(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list disk attachments of VM %s", id)
+	}
+	providerStatus.DiskIDs = make([]string, 0, len(diskAttachments))
+	for _, diskAttachment := range diskAttachments {
+		providerStatus.DiskIDs = append(providerStatus.DiskIDs, string(diskAttachment.DiskID()))
+	}
+
+	nics, err := instance.ListNICs(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list NICs of VM %s", id)
+	}
+	providerStatus.NICIDs = make([]string, 0, len(nics))
+	for _, nic := range nics {
+		providerStatus.NICIDs = append(providerStatus.NICIDs, string(nic.ID()))
+	}
+
+	phase, err := ms.creationPhase()
+	if err != nil {
+		return err
+	}
+	providerStatus.CreationPhase = phase
+	ms.reconcileConditions(providerStatus, instance.Status(), phase)
+
+	if succeeded {
+		now := metav1.Now()
+		providerStatus.LastSuccessfulReconcile = &now
+	}
+
 	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
 	if err != nil {
 		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
@@ -419,6 +737,56 @@ func (ms *machineScope) reconcileMachineProviderStatus(status string, id *string
 	return nil
 }
 
+// reconcileConditions sets the conditions of the provider status from the creation phase and the status of the VM.
+func (ms *machineScope) reconcileConditions(providerStatus *ovirtconfigv1.OvirtMachineProviderStatus,
+	status ovirtC.VMStatus, phase string) {
+	phaseConditions := []struct {
+		conditionType string
+		phase         string
+	}{
+		{ovirtconfigv1.VMCreatedCondition, creationPhaseCloning},
+		{ovirtconfigv1.DisksReadyCondition, creationPhaseConfiguringDisks},
+		{ovirtconfigv1.NICsReadyCondition, creationPhaseConfiguringNICs},
+	}
+	for _, phaseCondition := range phaseConditions {
+		conditionStatus := metav1.ConditionFalse
+		if creationPhaseCompleted(phase, phaseCondition.phase) {
+			conditionStatus = metav1.ConditionTrue
+		}
+		ms.setCondition(providerStatus, phaseCondition.conditionType, conditionStatus, phase,
+			fmt.Sprintf("VM is in creation phase %s", phase))
+	}
+
+	if status == ovirtC.VMStatusUp {
+		ms.setCondition(providerStatus, ovirtconfigv1.VMRunningCondition, metav1.ConditionTrue, "VMUp",
+			"VM is up")
+	} else {
+		ms.setCondition(providerStatus, ovirtconfigv1.VMRunningCondition, metav1.ConditionFalse, "VMNotUp",
+			fmt.Sprintf("VM status is %s", status))
+	}
+
+	for _, address := range ms.machine.Status.Addresses {
+		if address.Type == corev1.NodeInternalIP {
+			ms.setCondition(providerStatus, ovirtconfigv1.AddressesReportedCondition, metav1.ConditionTrue,
+				"AddressReported", fmt.Sprintf("guest agent reported address %s", address.Address))
+			return
+		}
+	}
+	ms.setCondition(providerStatus, ovirtconfigv1.AddressesReportedCondition, metav1.ConditionFalse,
+		"WaitingForAddress", "guest agent didn't report a usable address yet")
+}
+
+func (ms *machineScope) setCondition(providerStatus *ovirtconfigv1.OvirtMachineProviderStatus,
+	conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&providerStatus.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: ms.machine.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
 func (ms *machineScope) reconcileMachineProviderID(id string) {
 	providerID := utils.ProviderIDPrefix + id
 	ms.machine.Spec.ProviderID = &providerID
@@ -435,6 +803,7 @@ func (ms *machineScope) reconcileMachineAnnotations(status string, id string) {
 func (ms *machineScope) buildOptionalVMParameters(ignition string, templateID ovirtC.TemplateID) (ovirtC.BuildableVMParameters, error) {
 	optionalVMParams := ovirtC.CreateVMParams()
 	optionalVMParams = optionalVMParams.MustWithInitializationParameters(ignition, ms.machine.Name)
+	optionalVMParams = optionalVMParams.MustWithComment(ms.vmComment())
 
 	if ms.machineProviderSpec.VMType != "" {
 		optionalVMParams = optionalVMParams.MustWithVMType(ovirtC.VMType(ms.machineProviderSpec.VMType))
@@ -462,22 +831,14 @@ func (ms *machineScope) buildOptionalVMParameters(ignition string, templateID ov
 	}
 
 	optionalPlacementPolicy := ovirtC.NewVMPlacementPolicyParameters()
-	if ms.isAutoPinning() {
-		hosts, err := ms.ovirtClient.ListHosts(ovirtC.ContextStrategy(ms.Context))
+	hostIDs, err := ms.placementHostIDs()
+	if err != nil {
+		return nil, err
+	}
+	if len(hostIDs) > 0 {
+		optionalPlacementPolicy, err = optionalPlacementPolicy.WithHostIDs(hostIDs)
 		if err != nil {
-			return nil, errors.Wrap(err, "error Listing hosts")
-		}
-		hostIDs := make([]ovirtC.HostID, 0)
-		for _, host := range hosts {
-			if string(host.ClusterID()) == ms.machineProviderSpec.ClusterId {
-				hostIDs = append(hostIDs, host.ID())
-			}
-		}
-		if len(hostIDs) > 0 {
-			optionalPlacementPolicy, err = optionalPlacementPolicy.WithHostIDs(hostIDs)
-			if err != nil {
-				return nil, errors.Wrapf(err, "failed to create placement policy parameters with host IDs: %v", hostIDs)
-			}
+			return nil, errors.Wrapf(err, "failed to create placement policy parameters with host IDs: %v", hostIDs)
 		}
 	}
 
@@ -485,12 +846,17 @@ func (ms *machineScope) buildOptionalVMParameters(ignition string, templateID ov
 		optionalVMParams = optionalVMParams.MustWithHugePages(ovirtC.VMHugePages(ms.machineProviderSpec.Hugepages))
 	}
 
-	// Handle Sparse disks and Format
-	if ms.machineProviderSpec.Sparse != nil || ms.machineProviderSpec.Format != "" {
+	// Handle Sparse disks, Format and the storage domain of the template disks
+	if ms.machineProviderSpec.Sparse != nil || ms.machineProviderSpec.Format != "" ||
+		ms.machineProviderSpec.StorageDomainId != "" || ms.machineProviderSpec.StorageDomainSelector != nil {
 		tempDiskAttachment, err := ms.ovirtClient.ListTemplateDiskAttachments(templateID, ovirtC.ContextStrategy(ms.Context))
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to fetch template %s disk attachments from oVirt Engine",
-				ms.machineProviderSpec.TemplateName)
+				templateID)
+		}
+		storageDomainID, err := ms.templateDisksStorageDomainID(tempDiskAttachment)
+		if err != nil {
+			return nil, err
 		}
 
 		diskParams := []ovirtC.OptionalVMDiskParameters{}
@@ -504,6 +870,10 @@ func (ms *machineScope) buildOptionalVMParameters(ignition string, templateID ov
 				diskBuilder.MustWithFormat(ovirtC.ImageFormat(ms.machineProviderSpec.Format))
 			}
 
+			if storageDomainID != "" {
+				diskBuilder.MustWithStorageDomainID(storageDomainID)
+			}
+
 			diskParams = append(diskParams, diskBuilder)
 		}
 		optionalVMParams = optionalVMParams.MustWithDisks(diskParams)
@@ -520,17 +890,6 @@ func (ms *machineScope) buildOptionalVMParameters(ignition string, templateID ov
 		}
 	}
 
-	if ms.machineProviderSpec.StorageDomainId != "" {
-		tempDiskAttachment, err := ms.ovirtClient.ListTemplateDiskAttachments(templateID, ovirtC.ContextStrategy(ms.Context))
-		if err != nil {
-			return nil, errors.Wrapf(err, "failed to fetch template %s disk attachments from oVirt Engine",
-				ms.machineProviderSpec.TemplateName)
-		}
-		optionalVMParams = optionalVMParams.MustWithDisks([]ovirtC.OptionalVMDiskParameters{
-			ovirtC.MustNewBuildableVMDiskParameters(tempDiskAttachment[0].DiskID()).MustWithStorageDomainID(ovirtC.StorageDomainID(ms.machineProviderSpec.StorageDomainId)),
-		})
-	}
-
 	vmAffinity := ovirtC.VMAffinityMigratable
 	// apply high_performance rules
 	// see: https://access.redhat.com/documentation/en-us/red_hat_virtualization/4.4/html-single/virtual_machine_management_guide/index?extIdCarryOver=true&sc_cid=701f2000001Css5AAC#Automatic_High_Performance_Configuration_Settings
@@ -548,12 +907,34 @@ func (ms *machineScope) buildOptionalVMParameters(ignition string, templateID ov
 		optionalVMParams = optionalVMParams.WithMemoryPolicy(memPolicy)
 	}
 
+	if consoles := ms.machineProviderSpec.Consoles; consoles != nil {
+		if consoles.SerialConsole != nil {
+			optionalVMParams = optionalVMParams.WithSerialConsole(*consoles.SerialConsole)
+		}
+		if consoles.Soundcard != nil {
+			optionalVMParams = optionalVMParams.WithSoundcardEnabled(*consoles.Soundcard)
+		}
+	}
+
+	if ms.machineProviderSpec.Placement != nil && ms.machineProviderSpec.Placement.Affinity != "" {
+		vmAffinity = ovirtC.VMAffinity(ms.machineProviderSpec.Placement.Affinity)
+	}
 	optionalPlacementPolicy = optionalPlacementPolicy.MustWithAffinity(vmAffinity)
 	optionalVMParams = optionalVMParams.WithPlacementPolicy(optionalPlacementPolicy)
 
 	return optionalVMParams, nil
 }
 
+// hasNetworkConfig returns true if any of the network interfaces has a static network configuration.
+func (ms *machineScope) hasNetworkConfig() bool {
+	for _, nic := range ms.machineProviderSpec.NetworkInterfaces {
+		if nic.NetworkConfig != nil {
+			return true
+		}
+	}
+	return false
+}
+
 func (ms *machineScope) isAutoPinning() bool {
 	return ms.machineProviderSpec.AutoPinningPolicy != "" && ms.machineProviderSpec.AutoPinningPolicy != "none"
 }
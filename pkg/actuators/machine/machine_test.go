@@ -3,6 +3,7 @@
 package machine
 
 import (
+	"context"
 	"testing"
 
 	machinev1 "github.com/openshift/api/machine/v1beta1"
@@ -11,6 +12,7 @@ import (
 	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
 	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
 	k8sCorev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -81,6 +83,80 @@ func TestMachineScope_BuildOptionalVMParameter(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "verify placement on hosts with affinity",
+			setup: func(
+				basicSpec *v1beta1.OvirtMachineProviderSpec,
+				basicClient ovirtclient.Client) {
+				basicSpec.Placement = &v1beta1.Placement{
+					HostIDs:  []string{"host-1", "host-2"},
+					Affinity: "pinned",
+				}
+			},
+			verify: func(t *testing.T, params ovirtclient.OptionalVMParameters) {
+				placementPolicy := *params.PlacementPolicy()
+				if len(placementPolicy.HostIDs()) != 2 || placementPolicy.HostIDs()[0] != "host-1" {
+					t.Errorf("Expected placement host IDs to be [host-1 host-2], but got %v", placementPolicy.HostIDs())
+				}
+				if *placementPolicy.Affinity() != ovirtclient.VMAffinityPinned {
+					t.Errorf("Expected placement affinity to be %s, but got %s",
+						ovirtclient.VMAffinityPinned, *placementPolicy.Affinity())
+				}
+			},
+		},
+		{
+			name: "verify default placement affinity of high performance VMs",
+			setup: func(
+				basicSpec *v1beta1.OvirtMachineProviderSpec,
+				basicClient ovirtclient.Client) {
+				basicSpec.VMType = string(ovirtclient.VMTypeHighPerformance)
+			},
+			verify: func(t *testing.T, params ovirtclient.OptionalVMParameters) {
+				placementPolicy := *params.PlacementPolicy()
+				if len(placementPolicy.HostIDs()) != 0 {
+					t.Errorf("Expected no placement host IDs, but got %v", placementPolicy.HostIDs())
+				}
+				if *placementPolicy.Affinity() != ovirtclient.VMAffinityUserMigratable {
+					t.Errorf("Expected placement affinity to be %s, but got %s",
+						ovirtclient.VMAffinityUserMigratable, *placementPolicy.Affinity())
+				}
+			},
+		},
+		{
+			name: "verify serial console and soundcard of server VMs",
+			setup: func(
+				basicSpec *v1beta1.OvirtMachineProviderSpec,
+				basicClient ovirtclient.Client) {
+				enabled, disabled := true, false
+				basicSpec.Consoles = &v1beta1.Consoles{SerialConsole: &enabled, Soundcard: &disabled}
+			},
+			verify: func(t *testing.T, params ovirtclient.OptionalVMParameters) {
+				if params.SerialConsole() == nil || !*params.SerialConsole() {
+					t.Errorf("Expected the serial console to be enabled")
+				}
+				if params.SoundcardEnabled() == nil || *params.SoundcardEnabled() {
+					t.Errorf("Expected the soundcard to be disabled")
+				}
+			},
+		},
+		{
+			name: "verify consoles override the high performance defaults",
+			setup: func(
+				basicSpec *v1beta1.OvirtMachineProviderSpec,
+				basicClient ovirtclient.Client) {
+				disabled := false
+				basicSpec.VMType = string(ovirtclient.VMTypeHighPerformance)
+				basicSpec.Consoles = &v1beta1.Consoles{SerialConsole: &disabled}
+			},
+			verify: func(t *testing.T, params ovirtclient.OptionalVMParameters) {
+				if params.SerialConsole() == nil || *params.SerialConsole() {
+					t.Errorf("Expected the serial console to be disabled")
+				}
+				if params.SoundcardEnabled() == nil || *params.SoundcardEnabled() {
+					t.Errorf("Expected the soundcard of the high performance VM to stay disabled")
+				}
+			},
+		},
 	}
 
 	for _, testcase := range testcases {
@@ -117,6 +193,189 @@ func TestMachineScope_BuildOptionalVMParameter(t *testing.T) {
 	}
 }
 
+func TestMachineScope_ReconcileMachineProviderStatus(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	testcases := []struct {
+		name               string
+		phase              string
+		succeeded          bool
+		expectedConditions map[string]v1.ConditionStatus
+	}{
+		{
+			name:      "VM configuring NICs",
+			phase:     creationPhaseConfiguringNICs,
+			succeeded: true,
+			expectedConditions: map[string]v1.ConditionStatus{
+				v1beta1.VMCreatedCondition:         v1.ConditionTrue,
+				v1beta1.DisksReadyCondition:        v1.ConditionTrue,
+				v1beta1.NICsReadyCondition:         v1.ConditionFalse,
+				v1beta1.VMRunningCondition:         v1.ConditionFalse,
+				v1beta1.AddressesReportedCondition: v1.ConditionFalse,
+			},
+		},
+		{
+			name:      "VM cloning with failed reconcile",
+			phase:     creationPhaseCloning,
+			succeeded: false,
+			expectedConditions: map[string]v1.ConditionStatus{
+				v1beta1.VMCreatedCondition:  v1.ConditionFalse,
+				v1beta1.DisksReadyCondition: v1.ConditionFalse,
+				v1beta1.NICsReadyCondition:  v1.ConditionFalse,
+			},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			ms := machineScope{
+				Context:     context.Background(),
+				logger:      ovirt.NewKLogr("test"),
+				ovirtClient: ovirtClient,
+				machine:     &machinev1.Machine{ObjectMeta: v1.ObjectMeta{Name: "test-machine"}},
+			}
+			if err := ms.setCreationPhase(testcase.phase); err != nil {
+				t.Fatalf("Unexpected error occurred while recording the creation phase: %v", err)
+			}
+
+			if err := ms.reconcileMachineProviderStatus(vm, testcase.succeeded); err != nil {
+				t.Fatalf("Unexpected error occurred while reconciling the provider status: %v", err)
+			}
+
+			providerStatus, err := v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+			if err != nil {
+				t.Fatalf("Failed to read provider status: %v", err)
+			}
+			if *providerStatus.InstanceID != string(vm.ID()) {
+				t.Errorf("Expected instance ID %s, but got %s", vm.ID(), *providerStatus.InstanceID)
+			}
+			if providerStatus.TemplateID != string(template.ID()) {
+				t.Errorf("Expected template ID %s, but got %s", template.ID(), providerStatus.TemplateID)
+			}
+			if providerStatus.ClusterID != string(helper.GetClusterID()) {
+				t.Errorf("Expected cluster ID %s, but got %s", helper.GetClusterID(), providerStatus.ClusterID)
+			}
+			if providerStatus.CreationPhase != testcase.phase {
+				t.Errorf("Expected creation phase %s, but got %s", testcase.phase, providerStatus.CreationPhase)
+			}
+			if testcase.succeeded != (providerStatus.LastSuccessfulReconcile != nil) {
+				t.Errorf("Expected last successful reconcile to be set only on success, but got %v",
+					providerStatus.LastSuccessfulReconcile)
+			}
+			for conditionType, expectedStatus := range testcase.expectedConditions {
+				condition := meta.FindStatusCondition(providerStatus.Conditions, conditionType)
+				if condition == nil {
+					t.Errorf("Expected condition %s to be set", conditionType)
+					continue
+				}
+				if condition.Status != expectedStatus {
+					t.Errorf("Expected condition %s to be %s, but got %s", conditionType, expectedStatus, condition.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestMachineScope_GetVM(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	// the comment written at the creation of the VM proves that the VM belongs to the machine
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine",
+		ovirtclient.NewCreateVMParams().MustWithComment(vmCommentPrefix+" uid=test-uid"))
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	// a VM of another cluster sharing the engine with the same name as the machine
+	if _, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "other-machine", nil); err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	providerID := "ovirt://" + string(vm.ID())
+	missingProviderID := "ovirt://00000000-0000-0000-0000-000000000000"
+	instanceID := string(vm.ID())
+
+	testcases := []struct {
+		name           string
+		machineName    string
+		providerID     *string
+		instanceID     *string
+		expectNotFound bool
+	}{
+		{
+			name:        "VM is found by name without ID",
+			machineName: "test-machine",
+		},
+		{
+			name:        "VM is found by provider ID after it was renamed",
+			machineName: "renamed-machine",
+			providerID:  &providerID,
+		},
+		{
+			name:        "VM is found by instance ID before the provider ID is set",
+			machineName: "renamed-machine",
+			instanceID:  &instanceID,
+		},
+		{
+			name:           "VM with the machine name isn't used when the VM of the provider ID is gone",
+			machineName:    "other-machine",
+			providerID:     &missingProviderID,
+			expectNotFound: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			providerStatus, err := v1beta1.RawExtensionFromProviderStatus(
+				&v1beta1.OvirtMachineProviderStatus{InstanceID: testcase.instanceID})
+			if err != nil {
+				t.Fatalf("Failed to build provider status: %v", err)
+			}
+			ms := machineScope{
+				Context:     context.Background(),
+				logger:      ovirt.NewKLogr("test"),
+				ovirtClient: ovirtClient,
+				machine: &machinev1.Machine{
+					ObjectMeta: v1.ObjectMeta{Name: testcase.machineName, UID: "test-uid"},
+					Spec:       machinev1.MachineSpec{ProviderID: testcase.providerID},
+					Status:     machinev1.MachineStatus{ProviderStatus: providerStatus},
+				},
+			}
+
+			found, err := ms.getVM()
+			if testcase.expectNotFound {
+				if err == nil || !ovirtclient.HasErrorCode(err, ovirtclient.ENotFound) {
+					t.Fatalf("Expected the VM not to be found, but got VM %v and error %v", found, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error occurred while getting the VM: %v", err)
+			}
+			if found.ID() != vm.ID() {
+				t.Errorf("Expected VM %s, but got %s", vm.ID(), found.ID())
+			}
+		})
+	}
+}
+
 func basicMachineProviderSpec(templateName string, clusterID string) *v1beta1.OvirtMachineProviderSpec {
 	return &v1beta1.OvirtMachineProviderSpec{
 		ClusterId:    clusterID,
@@ -139,3 +398,112 @@ func basicMachineProviderSpec(templateName string, clusterID string) *v1beta1.Ov
 		GuaranteedMemoryMB: 10000,
 	}
 }
+
+func TestMachineScope_ReconcileAdditionalDisks(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	// a disk of a machine with the same name in another cluster, or preserved from a deleted machine
+	foreignDisk, err := ovirtClient.CreateDisk(helper.GetStorageDomainID(), ovirtclient.ImageFormatCow, 1024*1024,
+		ovirtclient.CreateDiskParams().MustWithAlias("test-machine_data1"))
+	if err != nil {
+		t.Fatalf("Failed to create disk: %v", err)
+	}
+
+	spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+	spec.AdditionalDisks = []*v1beta1.AdditionalDisk{{SizeGB: 1, StorageDomainId: string(helper.GetStorageDomainID())}}
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		ovirtClient:         ovirtClient,
+		machineProviderSpec: spec,
+		machine:             &machinev1.Machine{ObjectMeta: v1.ObjectMeta{Name: "test-machine"}},
+	}
+
+	ready := false
+	for i := 0; i < 10 && !ready; i++ {
+		if ready, err = ms.reconcileAdditionalDisks(vm); err != nil {
+			t.Fatalf("Unexpected error occurred while reconciling the additional disks: %v", err)
+		}
+	}
+	if !ready {
+		t.Fatalf("Expected the additional disks to be ready")
+	}
+
+	providerStatus, err := v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		t.Fatalf("Failed to read provider status: %v", err)
+	}
+	if len(providerStatus.AdditionalDiskIDs) != 1 || providerStatus.AdditionalDiskIDs[0] == string(foreignDisk.ID()) {
+		t.Fatalf("Expected the ID of a new disk to be recorded, but got %v", providerStatus.AdditionalDiskIDs)
+	}
+	diskAttachments, err := vm.ListDiskAttachments()
+	if err != nil {
+		t.Fatalf("Failed to list disk attachments: %v", err)
+	}
+	if len(diskAttachments) != 1 || string(diskAttachments[0].DiskID()) != providerStatus.AdditionalDiskIDs[0] {
+		t.Errorf("Expected only disk %s to be attached, but got %v", providerStatus.AdditionalDiskIDs[0], diskAttachments)
+	}
+}
+
+func TestMachineScope_RemoveUnattachedAdditionalDisks(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	attachedDisk, err := ovirtClient.CreateDisk(helper.GetStorageDomainID(), ovirtclient.ImageFormatCow, 1024*1024, nil)
+	if err != nil {
+		t.Fatalf("Failed to create disk: %v", err)
+	}
+	if _, err := vm.AttachDisk(attachedDisk.ID(), ovirtclient.DiskInterfaceVirtIOSCSI, nil); err != nil {
+		t.Fatalf("Failed to attach disk: %v", err)
+	}
+	unattachedDisk, err := ovirtClient.CreateDisk(helper.GetStorageDomainID(), ovirtclient.ImageFormatCow, 1024*1024, nil)
+	if err != nil {
+		t.Fatalf("Failed to create disk: %v", err)
+	}
+	providerStatus, err := v1beta1.RawExtensionFromProviderStatus(&v1beta1.OvirtMachineProviderStatus{
+		AdditionalDiskIDs: []string{string(attachedDisk.ID()), string(unattachedDisk.ID()), "removed-disk"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to build provider status: %v", err)
+	}
+	ms := machineScope{
+		Context:     context.Background(),
+		logger:      ovirt.NewKLogr("test"),
+		ovirtClient: ovirtClient,
+		machine: &machinev1.Machine{
+			ObjectMeta: v1.ObjectMeta{Name: "test-machine"},
+			Status:     machinev1.MachineStatus{ProviderStatus: providerStatus},
+		},
+	}
+
+	if err := ms.removeUnattachedAdditionalDisks(vm); err != nil {
+		t.Fatalf("Unexpected error occurred while removing the unattached disks: %v", err)
+	}
+	if _, err := ovirtClient.GetDisk(attachedDisk.ID()); err != nil {
+		t.Errorf("Expected the attached disk to be kept, but got error %v", err)
+	}
+	if _, err := ovirtClient.GetDisk(unattachedDisk.ID()); err == nil || !ovirtclient.HasErrorCode(err, ovirtclient.ENotFound) {
+		t.Errorf("Expected the unattached disk to be removed, but got error %v", err)
+	}
+}
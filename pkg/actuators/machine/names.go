@@ -0,0 +1,147 @@
+package machine
+
+import (
+	"fmt"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+// resolveSpecNames replaces the cluster, instance type and vNic profile names of the spec with their IDs, so the
+// rest of the actuator only deals with IDs. A name which resolves to another ID than the one given in the
+// spec is an error.
+func resolveSpecNames(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	if config.ClusterName != "" {
+		clusterID, err := resolveClusterName(ovirtClient, config.ClusterName)
+		if err != nil {
+			return err
+		}
+		if config.ClusterId != "" && config.ClusterId != string(clusterID) {
+			return fmt.Errorf("cluster name %s resolves to cluster %s, which conflicts with cluster ID %s",
+				config.ClusterName, clusterID, config.ClusterId)
+		}
+		config.ClusterId = string(clusterID)
+	}
+
+	if config.InstanceTypeName != "" {
+		instanceTypeID, err := resolveInstanceTypeName(ovirtClient, config.InstanceTypeName)
+		if err != nil {
+			return err
+		}
+		if config.InstanceTypeId != "" && config.InstanceTypeId != string(instanceTypeID) {
+			return fmt.Errorf("instance type name %s resolves to instance type %s, which conflicts with instance type ID %s",
+				config.InstanceTypeName, instanceTypeID, config.InstanceTypeId)
+		}
+		config.InstanceTypeId = string(instanceTypeID)
+	}
+
+	for i, nic := range config.NetworkInterfaces {
+		if nic == nil || nic.VNICProfileName == "" {
+			continue
+		}
+		vnicProfileID, err := resolveVNICProfileName(ovirtClient, nic.Network, nic.VNICProfileName)
+		if err != nil {
+			return errors.Wrapf(err, "network interface %d", i)
+		}
+		if nic.VNICProfileID != "" && nic.VNICProfileID != string(vnicProfileID) {
+			return fmt.Errorf("vNic profile %s of network %s resolves to vNic profile %s, "+
+				"which conflicts with vNic profile ID %s of network interface %d",
+				nic.VNICProfileName, nic.Network, vnicProfileID, nic.VNICProfileID, i)
+		}
+		nic.VNICProfileID = string(vnicProfileID)
+	}
+	return nil
+}
+
+func resolveClusterName(ovirtClient ovirtC.Client, name string) (ovirtC.ClusterID, error) {
+	clusters, err := ovirtClient.ListClusters()
+	if err != nil {
+		return "", newEngineError(err, "failed to list clusters")
+	}
+	for _, cluster := range clusters {
+		if cluster.Name() == name {
+			return cluster.ID(), nil
+		}
+	}
+	return "", fmt.Errorf("cluster name %s not found", name)
+}
+
+func resolveInstanceTypeName(ovirtClient ovirtC.Client, name string) (ovirtC.InstanceTypeID, error) {
+	instanceTypes, err := ovirtClient.ListInstanceTypes()
+	if err != nil {
+		return "", newEngineError(err, "failed to list instance types")
+	}
+	for _, instanceType := range instanceTypes {
+		if instanceType.Name() == name {
+			return instanceType.ID(), nil
+		}
+	}
+	return "", fmt.Errorf("instance type name %s not found", name)
+}
+
+// resolveVNICProfileName returns the ID of the vNic profile with the given name in the network with the given name.
+// Networks of different datacenters may share their name, the profile is ambiguous then.
+func resolveVNICProfileName(ovirtClient ovirtC.Client, networkName string, name string) (ovirtC.VNICProfileID, error) {
+	networks, err := ovirtClient.ListNetworks()
+	if err != nil {
+		return "", newEngineError(err, "failed to list networks")
+	}
+	networkIDs := map[ovirtC.NetworkID]bool{}
+	for _, network := range networks {
+		if network.Name() == networkName {
+			networkIDs[network.ID()] = true
+		}
+	}
+	if len(networkIDs) == 0 {
+		return "", fmt.Errorf("network name %s not found", networkName)
+	}
+
+	vnicProfiles, err := ovirtClient.ListVNICProfiles()
+	if err != nil {
+		return "", newEngineError(err, "failed to list vNic profiles")
+	}
+	var matches []ovirtC.VNICProfileID
+	for _, vnicProfile := range vnicProfiles {
+		if vnicProfile.Name() == name && networkIDs[vnicProfile.NetworkID()] {
+			matches = append(matches, vnicProfile.ID())
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("vNic profile name %s not found in network %s", name, networkName)
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("vNic profile name %s matches %d profiles of networks named %s in different datacenters, "+
+			"use vnic_profile_id instead", name, len(matches), networkName)
+	}
+}
+
+// resolveNames resolves the names of the machine spec and records the resolved IDs in the provider status.
+func (ms *machineScope) resolveNames() error {
+	if err := resolveSpecNames(ms.ovirtClient, ms.machineProviderSpec); err != nil {
+		return err
+	}
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	if providerStatus.ClusterID == "" {
+		// the cluster ID is taken from the VM once it exists
+		providerStatus.ClusterID = ms.machineProviderSpec.ClusterId
+	}
+	providerStatus.InstanceTypeID = ms.machineProviderSpec.InstanceTypeId
+	providerStatus.VNICProfileIDs = nil
+	for _, nic := range ms.machineProviderSpec.NetworkInterfaces {
+		if nic != nil {
+			providerStatus.VNICProfileIDs = append(providerStatus.VNICProfileIDs, nic.VNICProfileID)
+		}
+	}
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
@@ -0,0 +1,169 @@
+//go:build unit
+
+package machine
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+)
+
+func TestResolveSpecNames(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	cluster, err := ovirtClient.GetCluster(helper.GetClusterID())
+	if err != nil {
+		t.Fatalf("Failed to get cluster: %v", err)
+	}
+	vnicProfile, err := ovirtClient.GetVNICProfile(helper.GetVNICProfileID())
+	if err != nil {
+		t.Fatalf("Failed to get vNic profile: %v", err)
+	}
+	network, err := vnicProfile.Network()
+	if err != nil {
+		t.Fatalf("Failed to get network: %v", err)
+	}
+	instanceTypeID := "00000005-0005-0005-0005-0000000002e6"
+
+	testcases := []struct {
+		name                  string
+		spec                  *v1beta1.OvirtMachineProviderSpec
+		expectErr             bool
+		expectedClusterID     string
+		expectedInstanceType  string
+		expectedVNICProfileID string
+	}{
+		{
+			name: "IDs are kept",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterId:         string(cluster.ID()),
+				NetworkInterfaces: []*v1beta1.NetworkInterface{{VNICProfileID: "profile"}},
+			},
+			expectedClusterID:     string(cluster.ID()),
+			expectedVNICProfileID: "profile",
+		},
+		{
+			name: "names are resolved",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterName:      cluster.Name(),
+				InstanceTypeName: "Small",
+				NetworkInterfaces: []*v1beta1.NetworkInterface{
+					{Network: network.Name(), VNICProfileName: vnicProfile.Name()},
+				},
+			},
+			expectedClusterID:     string(cluster.ID()),
+			expectedInstanceType:  instanceTypeID,
+			expectedVNICProfileID: string(vnicProfile.ID()),
+		},
+		{
+			name: "matching names and IDs are accepted",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterId:        string(cluster.ID()),
+				ClusterName:      cluster.Name(),
+				InstanceTypeId:   instanceTypeID,
+				InstanceTypeName: "Small",
+				NetworkInterfaces: []*v1beta1.NetworkInterface{{
+					VNICProfileID:   string(vnicProfile.ID()),
+					Network:         network.Name(),
+					VNICProfileName: vnicProfile.Name(),
+				}},
+			},
+			expectedClusterID:     string(cluster.ID()),
+			expectedInstanceType:  instanceTypeID,
+			expectedVNICProfileID: string(vnicProfile.ID()),
+		},
+		{
+			name: "conflicting cluster name and ID fail",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterId:   "46991e3f-8752-4ab6-9f2d-c37a98358d52",
+				ClusterName: cluster.Name(),
+			},
+			expectErr: true,
+		},
+		{
+			name: "conflicting instance type name and ID fail",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterId:        string(cluster.ID()),
+				InstanceTypeId:   instanceTypeID,
+				InstanceTypeName: "Large",
+			},
+			expectErr: true,
+		},
+		{
+			name: "conflicting vNic profile name and ID fail",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterId: string(cluster.ID()),
+				NetworkInterfaces: []*v1beta1.NetworkInterface{{
+					VNICProfileID:   "profile",
+					Network:         network.Name(),
+					VNICProfileName: vnicProfile.Name(),
+				}},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown vNic profile name fails",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterId: string(cluster.ID()),
+				NetworkInterfaces: []*v1beta1.NetworkInterface{
+					{Network: network.Name(), VNICProfileName: "missing"},
+				},
+			},
+			expectErr: true,
+		},
+		{
+			name: "unknown cluster name fails",
+			spec: &v1beta1.OvirtMachineProviderSpec{
+				ClusterName: "missing",
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			err := resolveSpecNames(ovirtClient, testcase.spec)
+			if testcase.expectErr {
+				if err == nil {
+					t.Errorf("Expected resolving the names to fail")
+				} else if isEngineError(err) {
+					t.Errorf("Expected a configuration error, but got an engine error: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error occurred while resolving the names: %v", err)
+			}
+			if testcase.spec.ClusterId != testcase.expectedClusterID {
+				t.Errorf("Expected cluster ID %s, but got %s", testcase.expectedClusterID, testcase.spec.ClusterId)
+			}
+			if testcase.spec.InstanceTypeId != testcase.expectedInstanceType {
+				t.Errorf("Expected instance type ID %s, but got %s", testcase.expectedInstanceType, testcase.spec.InstanceTypeId)
+			}
+			for _, nic := range testcase.spec.NetworkInterfaces {
+				if nic.VNICProfileID != testcase.expectedVNICProfileID {
+					t.Errorf("Expected vNic profile ID %s, but got %s", testcase.expectedVNICProfileID, nic.VNICProfileID)
+				}
+			}
+		})
+	}
+}
+
+func TestIsEngineError(t *testing.T) {
+	if isEngineError(fmt.Errorf("cluster %s not found", "missing")) {
+		t.Errorf("Expected a configuration error not to be an engine error")
+	}
+	err := newEngineError(fmt.Errorf("connection refused"), "failed to list clusters")
+	if !isEngineError(fmt.Errorf("failed to resolve names: %w", err)) {
+		t.Errorf("Expected a wrapped engine error to be an engine error")
+	}
+	if newEngineError(nil, "failed to list clusters") != nil {
+		t.Errorf("Expected no engine error without an error")
+	}
+}
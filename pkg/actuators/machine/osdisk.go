@@ -0,0 +1,82 @@
+package machine
+
+import (
+	"fmt"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// OSDiskShrinkRejectedReason is the reason of the event and the condition reporting an OS disk size of the spec
+// smaller than the bootable disk.
+const OSDiskShrinkRejectedReason = "OSDiskShrinkRejected"
+
+// reconcileOSDiskSize extends the bootable disk of a created VM online when the OS disk size of the spec
+// increases. oVirt can't shrink disks, so an OS disk size smaller than the bootable disk is rejected with an
+// event and the OSDiskSizeApplied condition instead of an error, the rest of the machine is still reconciled.
+func (ms *machineScope) reconcileOSDiskSize(vm ovirtC.VM) error {
+	if ms.machineProviderSpec.OSDisk == nil {
+		return nil
+	}
+	disk, err := ms.bootableDisk(vm)
+	if err != nil {
+		return err
+	}
+	desiredGB := ms.machineProviderSpec.OSDisk.SizeGB
+	if currentBytes := disk.ProvisionedSize(); uint64(desiredGB)*bytesInGB < currentBytes {
+		message := fmt.Sprintf("OS disk of VM %s has %dGiB, shrinking it to %dGiB is not supported",
+			vm.Name(), currentBytes/bytesInGB, desiredGB)
+		return ms.setOSDiskSizeCondition(metav1.ConditionFalse, OSDiskShrinkRejectedReason, message)
+	}
+	// the extension completes in the background, it is recorded by a later reconcile
+	ready, err := ms.reconcileOSDisk(vm)
+	if err != nil {
+		return err
+	}
+	if !ready {
+		return ms.setOSDiskSizeCondition(metav1.ConditionFalse, "Extending",
+			fmt.Sprintf("OS disk of VM %s is extended to %dGiB", vm.Name(), desiredGB))
+	}
+	return ms.setOSDiskSizeCondition(metav1.ConditionTrue, "OSDiskSizeApplied",
+		fmt.Sprintf("OS disk of VM %s has the size of the spec", vm.Name()))
+}
+
+// setOSDiskSizeCondition sets the OSDiskSizeApplied condition of the provider status. The rejection of a shrink
+// is reported by an event once, when the condition changes to it.
+func (ms *machineScope) setOSDiskSizeCondition(status metav1.ConditionStatus, reason string, message string) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	previous := meta.FindStatusCondition(providerStatus.Conditions, ovirtconfigv1.OSDiskSizeAppliedCondition)
+	if reason == OSDiskShrinkRejectedReason && (previous == nil || previous.Reason != reason) {
+		ms.logger.Warningf("%s", message)
+		ms.eventf(corev1.EventTypeWarning, OSDiskShrinkRejectedReason, "%s", message)
+	}
+	ms.setCondition(providerStatus, ovirtconfigv1.OSDiskSizeAppliedCondition, status, reason, message)
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
+
+// setOSDiskSize records the OS disk size the bootable disk was extended to.
+func (ms *machineScope) setOSDiskSize(sizeGB int64) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	providerStatus.OSDiskSizeGB = sizeGB
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
@@ -0,0 +1,143 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineScope_ReconcileOSDiskSize(t *testing.T) {
+	testcases := []struct {
+		name             string
+		recordedSizeGB   int64
+		desiredSizeGB    int64
+		expectRejected   bool
+		expectedDiskSize uint64
+	}{
+		{
+			name:             "increased size extends the disk",
+			recordedSizeGB:   2,
+			desiredSizeGB:    3,
+			expectedDiskSize: 3 * bytesInGB,
+		},
+		{
+			name:             "unchanged size keeps the disk",
+			recordedSizeGB:   2,
+			desiredSizeGB:    2,
+			expectedDiskSize: 2 * bytesInGB,
+		},
+		{
+			name:             "decreased size is rejected",
+			recordedSizeGB:   2,
+			desiredSizeGB:    1,
+			expectRejected:   true,
+			expectedDiskSize: 2 * bytesInGB,
+		},
+		{
+			name:             "decreased size is rejected without a recorded size",
+			desiredSizeGB:    1,
+			expectRejected:   true,
+			expectedDiskSize: 2 * bytesInGB,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+			if err != nil {
+				t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+			}
+			ovirtClient := helper.GetClient()
+			template, err := ovirtClient.GetBlankTemplate()
+			if err != nil {
+				t.Fatalf("Failed to get blank template: %v", err)
+			}
+			vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+			if err != nil {
+				t.Fatalf("Failed to create VM: %v", err)
+			}
+			disk, err := ovirtClient.CreateDisk(helper.GetStorageDomainID(), ovirtclient.ImageFormatRaw, 2*bytesInGB, nil)
+			if err != nil {
+				t.Fatalf("Failed to create disk: %v", err)
+			}
+			if _, err := ovirtClient.CreateDiskAttachment(vm.ID(), disk.ID(), ovirtclient.DiskInterfaceVirtIO,
+				ovirtclient.CreateDiskAttachmentParams().MustWithBootable(true)); err != nil {
+				t.Fatalf("Failed to attach disk: %v", err)
+			}
+
+			providerStatus, err := v1beta1.RawExtensionFromProviderStatus(&v1beta1.OvirtMachineProviderStatus{
+				OSDiskSizeGB: testcase.recordedSizeGB,
+			})
+			if err != nil {
+				t.Fatalf("Failed to build provider status: %v", err)
+			}
+			spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+			spec.OSDisk.SizeGB = testcase.desiredSizeGB
+			ms := machineScope{
+				Context:             context.Background(),
+				logger:              ovirt.NewKLogr("test"),
+				ovirtClient:         ovirtClient,
+				machineProviderSpec: spec,
+				machine: &machinev1.Machine{
+					ObjectMeta: v1.ObjectMeta{Name: "test-machine"},
+					Status:     machinev1.MachineStatus{ProviderStatus: providerStatus},
+				},
+			}
+
+			// a rejected shrink doesn't fail the reconcile
+			if err := ms.reconcileOSDiskSize(vm); err != nil {
+				t.Fatalf("Unexpected error occurred while reconciling the OS disk size: %v", err)
+			}
+			status, err := v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+			if err != nil {
+				t.Fatalf("Failed to read provider status: %v", err)
+			}
+			condition := meta.FindStatusCondition(status.Conditions, v1beta1.OSDiskSizeAppliedCondition)
+			if rejected := condition != nil && condition.Reason == OSDiskShrinkRejectedReason; rejected != testcase.expectRejected {
+				t.Fatalf("Expected the shrink to be rejected %t, but got condition %+v", testcase.expectRejected, condition)
+			}
+
+			// the extension completes in the background
+			deadline := time.Now().Add(10 * time.Second)
+			for {
+				disk, err = ovirtClient.GetDisk(disk.ID())
+				if err != nil {
+					t.Fatalf("Failed to get disk: %v", err)
+				}
+				if disk.Status() == ovirtclient.DiskStatusOK || time.Now().After(deadline) {
+					break
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+			if disk.ProvisionedSize() != testcase.expectedDiskSize {
+				t.Errorf("Expected disk size to be %d, but got %d", testcase.expectedDiskSize, disk.ProvisionedSize())
+			}
+			if testcase.expectRejected {
+				return
+			}
+
+			if err := ms.reconcileOSDiskSize(vm); err != nil {
+				t.Fatalf("Unexpected error occurred while reconciling the OS disk size: %v", err)
+			}
+			status, err = v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+			if err != nil {
+				t.Fatalf("Failed to read provider status: %v", err)
+			}
+			if status.OSDiskSizeGB != testcase.desiredSizeGB {
+				t.Errorf("Expected recorded OS disk size to be %d, but got %d", testcase.desiredSizeGB, status.OSDiskSizeGB)
+			}
+			if !meta.IsStatusConditionTrue(status.Conditions, v1beta1.OSDiskSizeAppliedCondition) {
+				t.Errorf("Expected the OS disk size to be applied")
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+// VMNotOwnedMachineError is the reason of the error of a machine whose VM doesn't belong to its cluster.
+const VMNotOwnedMachineError machinev1.MachineStatusError = "VMNotOwned"
+
+// vmNotOwnedError is returned when a VM found for a machine doesn't belong to the cluster of the machine.
+type vmNotOwnedError struct {
+	vmName string
+	vmID   ovirtC.VMID
+	tag    string
+}
+
+func (e *vmNotOwnedError) Error() string {
+	return fmt.Sprintf("VM %s(%s) is neither tagged with the cluster tag %q nor commented with the UID of the "+
+		"machine, refusing to touch a VM of another cluster", e.vmName, e.vmID, e.tag)
+}
+
+// verifyOwnership returns a vmNotOwnedError if the VM doesn't belong to the cluster of the machine.
+// A VM belongs to the cluster if it is tagged with the cluster tag or if its comment carries the UID of the
+// machine. The comment is set when the VM is created, so it proves the ownership of a VM whose creation didn't
+// reach the tagging phase yet. The ID of the VM proves nothing, as the machine may have found the VM by name.
+// A machine without the cluster label can't prove the ownership by the tag, a VM without the comment is then
+// returned as a plain error, so the machine is retried instead of failing permanently.
+func (ms *machineScope) verifyOwnership(vm ovirtC.VM) error {
+	tag := ms.machine.Labels[utils.ClusterIDLabel]
+	if tag != "" {
+		tags, err := ms.ovirtClient.ListVMTags(vm.ID(), ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return errors.Wrapf(err, "failed to list tags of VM %s", vm.ID())
+		}
+		for _, vmTag := range tags {
+			if vmTag.Name() == tag {
+				return nil
+			}
+		}
+	}
+	if ms.machine.UID != "" && commentHasMachineUID(vm.Comment(), string(ms.machine.UID)) {
+		return nil
+	}
+	if tag == "" {
+		ms.logger.Warningf("machine %s has no %s label, the ownership of VM %s(%s) can't be verified",
+			ms.machine.Name, utils.ClusterIDLabel, vm.Name(), vm.ID())
+		return fmt.Errorf("machine %s has no %s label and VM %s(%s) isn't commented with its UID, refusing to "+
+			"touch a VM which may belong to another cluster", ms.machine.Name, utils.ClusterIDLabel, vm.Name(), vm.ID())
+	}
+	return &vmNotOwnedError{vmName: vm.Name(), vmID: vm.ID(), tag: tag}
+}
+
+// commentHasMachineUID returns true if the managed part of the VM comment, see managedVMComment, carries the UID
+// of the machine.
+func commentHasMachineUID(comment string, uid string) bool {
+	i := strings.Index(comment, vmCommentPrefix)
+	if i < 0 {
+		return false
+	}
+	for _, field := range strings.Fields(comment[i+len(vmCommentPrefix):]) {
+		if field == "uid="+uid {
+			return true
+		}
+	}
+	return false
+}
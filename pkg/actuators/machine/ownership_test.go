@@ -0,0 +1,229 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestMachineScope_VerifyOwnership(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	for _, tag := range []string{"cluster-a", "cluster-b"} {
+		if _, err := ovirtClient.CreateTag(tag, ovirtclient.NewCreateTagParams()); err != nil {
+			t.Fatalf("Failed to create tag %s: %v", tag, err)
+		}
+	}
+	taggedVM, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "worker-0", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	if err := ovirtClient.AddTagToVMByName(taggedVM.ID(), "cluster-a"); err != nil {
+		t.Fatalf("Failed to tag VM: %v", err)
+	}
+	untaggedVM, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "worker-1", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	untaggedVMID := string(untaggedVM.ID())
+	untaggedProviderID := utils.ProviderIDPrefix + untaggedVMID
+	commentedVM, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "worker-2",
+		ovirtclient.NewCreateVMParams().MustWithComment(vmCommentPrefix+" machine=openshift-machine-api/worker-2 uid=uid-2"))
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+
+	testcases := []struct {
+		name        string
+		vm          ovirtclient.VM
+		clusterTag  string
+		phase       string
+		instanceID  *string
+		providerID  *string
+		uid         types.UID
+		expectOwned bool
+		// expectRetry is set if the ownership can't be verified and the machine is retried
+		expectRetry bool
+	}{
+		{
+			name:        "VM with the cluster tag is owned",
+			vm:          taggedVM,
+			clusterTag:  "cluster-a",
+			phase:       creationPhaseCreated,
+			expectOwned: true,
+		},
+		{
+			name:        "VM with the tag of another cluster is not owned",
+			vm:          taggedVM,
+			clusterTag:  "cluster-b",
+			phase:       creationPhaseCreated,
+			expectOwned: false,
+		},
+		{
+			name:        "untagged VM with the machine UID in its comment before the tagging phase is owned",
+			vm:          commentedVM,
+			clusterTag:  "cluster-a",
+			phase:       creationPhaseConfiguringDisks,
+			uid:         "uid-2",
+			expectOwned: true,
+		},
+		{
+			name:        "untagged VM with the ID recorded by the machine before the tagging phase is not owned",
+			vm:          untaggedVM,
+			clusterTag:  "cluster-a",
+			phase:       creationPhaseConfiguringDisks,
+			instanceID:  &untaggedVMID,
+			expectOwned: false,
+		},
+		{
+			name:        "untagged VM of the provider ID of the machine is not owned",
+			vm:          untaggedVM,
+			clusterTag:  "cluster-a",
+			phase:       creationPhaseCreated,
+			providerID:  &untaggedProviderID,
+			expectOwned: false,
+		},
+		{
+			name:        "untagged VM with the machine UID in its comment is owned",
+			vm:          commentedVM,
+			clusterTag:  "cluster-a",
+			phase:       creationPhaseCreated,
+			uid:         "uid-2",
+			expectOwned: true,
+		},
+		{
+			name:        "untagged VM with the UID of another machine in its comment is not owned",
+			vm:          commentedVM,
+			clusterTag:  "cluster-a",
+			phase:       creationPhaseCreated,
+			uid:         "uid",
+			expectOwned: false,
+		},
+		{
+			name:        "VM of a machine without cluster label is owned by the machine UID in its comment",
+			vm:          commentedVM,
+			phase:       creationPhaseCreated,
+			uid:         "uid-2",
+			expectOwned: true,
+		},
+		{
+			name:        "VM of a machine without cluster label not commented with its UID is retried",
+			vm:          untaggedVM,
+			phase:       creationPhaseCreated,
+			providerID:  &untaggedProviderID,
+			expectRetry: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			providerStatus, err := v1beta1.RawExtensionFromProviderStatus(&v1beta1.OvirtMachineProviderStatus{
+				InstanceID:    testcase.instanceID,
+				CreationPhase: testcase.phase,
+			})
+			if err != nil {
+				t.Fatalf("Failed to build provider status: %v", err)
+			}
+			ms := machineScope{
+				Context:     context.Background(),
+				logger:      ovirt.NewKLogr("test"),
+				ovirtClient: ovirtClient,
+				machine: &machinev1.Machine{
+					ObjectMeta: v1.ObjectMeta{
+						Name:   testcase.vm.Name(),
+						UID:    testcase.uid,
+						Labels: map[string]string{utils.ClusterIDLabel: testcase.clusterTag},
+					},
+					Spec:   machinev1.MachineSpec{ProviderID: testcase.providerID},
+					Status: machinev1.MachineStatus{ProviderStatus: providerStatus},
+				},
+			}
+
+			err = ms.verifyOwnership(testcase.vm)
+			if testcase.expectOwned {
+				if err != nil {
+					t.Errorf("Expected VM to be owned, but got error %v", err)
+				}
+				return
+			}
+			var notOwnedError *vmNotOwnedError
+			if testcase.expectRetry {
+				if err == nil || errors.As(err, &notOwnedError) {
+					t.Errorf("Expected the ownership to be unverifiable, but got error %v", err)
+				}
+				return
+			}
+			if !errors.As(err, &notOwnedError) {
+				t.Errorf("Expected VM not to be owned, but got error %v", err)
+			}
+		})
+	}
+}
+
+func TestMachineScope_CreateRejectsForeignVMWithSameName(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	for _, tag := range []string{"cluster-a", "cluster-b"} {
+		if _, err := ovirtClient.CreateTag(tag, ovirtclient.NewCreateTagParams()); err != nil {
+			t.Fatalf("Failed to create tag %s: %v", tag, err)
+		}
+	}
+	foreignVM, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "worker-0",
+		ovirtclient.NewCreateVMParams().MustWithComment(vmCommentPrefix+" cluster=cluster-b uid=foreign"))
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	if err := ovirtClient.AddTagToVMByName(foreignVM.ID(), "cluster-b"); err != nil {
+		t.Fatalf("Failed to tag VM: %v", err)
+	}
+
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		ovirtClient:         ovirtClient,
+		machineProviderSpec: basicMachineProviderSpec(template.Name(), string(helper.GetClusterID())),
+		machine: &machinev1.Machine{
+			ObjectMeta: v1.ObjectMeta{
+				Name:   "worker-0",
+				UID:    "uid-0",
+				Labels: map[string]string{utils.ClusterIDLabel: "cluster-a"},
+			},
+		},
+	}
+
+	var notOwnedError *vmNotOwnedError
+	if err := ms.create(); !errors.As(err, &notOwnedError) {
+		t.Fatalf("Expected the VM of another cluster to be rejected, but got error %v", err)
+	}
+	if ms.machine.Spec.ProviderID != nil || ms.machine.Status.ProviderStatus != nil {
+		t.Errorf("Expected the machine not to record the VM of another cluster")
+	}
+	exists, err := ms.exists()
+	if err != nil || exists {
+		t.Errorf("Expected the VM of another cluster not to exist for the machine, but got %t and error %v", exists, err)
+	}
+}
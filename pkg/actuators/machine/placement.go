@@ -0,0 +1,78 @@
+package machine
+
+import (
+	"regexp"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+// placementHostIDs returns the IDs of the hosts the VM may run on. The hosts are taken from the placement
+// of the machine spec. Without an explicit placement, auto-pinning requires all hosts of the cluster.
+func (ms *machineScope) placementHostIDs() ([]ovirtC.HostID, error) {
+	placement := ms.machineProviderSpec.Placement
+	switch {
+	case placement != nil && len(placement.HostIDs) > 0:
+		hostIDs := make([]ovirtC.HostID, 0, len(placement.HostIDs))
+		for _, hostID := range placement.HostIDs {
+			hostIDs = append(hostIDs, ovirtC.HostID(hostID))
+		}
+		return hostIDs, nil
+	case placement != nil && placement.HostNameSelector != "":
+		return ms.listClusterHostIDsByName(placement.HostNameSelector)
+	case ms.isAutoPinning():
+		hosts, err := ms.ovirtClient.ListHosts(ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return nil, errors.Wrap(err, "error Listing hosts")
+		}
+		hostIDs := make([]ovirtC.HostID, 0)
+		for _, host := range hosts {
+			if string(host.ClusterID()) == ms.machineProviderSpec.ClusterId {
+				hostIDs = append(hostIDs, host.ID())
+			}
+		}
+		return hostIDs, nil
+	}
+	return nil, nil
+}
+
+// listClusterHostIDsByName returns the IDs of the hosts of the cluster whose name matches the selector.
+// go-ovirt-client doesn't expose the host names, so the oVirt SDK is used to list the hosts.
+func (ms *machineScope) listClusterHostIDsByName(selector string) ([]ovirtC.HostID, error) {
+	nameRegexp, err := regexp.Compile(selector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid host name selector %s", selector)
+	}
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return nil, err
+	}
+	response, err := conn.SystemService().HostsService().List().Send()
+	if err != nil {
+		return nil, errors.Wrap(err, "error Listing hosts")
+	}
+
+	hostIDs := make([]ovirtC.HostID, 0)
+	if hosts, ok := response.Hosts(); ok {
+		for _, host := range hosts.Slice() {
+			cluster, ok := host.Cluster()
+			if !ok {
+				continue
+			}
+			if clusterID, _ := cluster.Id(); clusterID != ms.machineProviderSpec.ClusterId {
+				continue
+			}
+			name, _ := host.Name()
+			id, ok := host.Id()
+			if ok && nameRegexp.MatchString(name) {
+				hostIDs = append(hostIDs, ovirtC.HostID(id))
+			}
+		}
+	}
+	if len(hostIDs) == 0 {
+		return nil, errors.Errorf("no host in cluster %s matches the host name selector %s",
+			ms.machineProviderSpec.ClusterId, selector)
+	}
+	return hostIDs, nil
+}
@@ -0,0 +1,91 @@
+package machine
+
+import (
+	"time"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	apierrors "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	shutdownModeForce    = "force"
+	shutdownModeGraceful = "graceful"
+
+	defaultShutdownTimeoutSeconds = 300
+	// shutdownRequeueAfter is the interval the status of a VM which is stopping is checked
+	shutdownRequeueAfter = 10 * time.Second
+)
+
+// stop stops the VM according to the shutdown policy of the machine. It never waits for the VM to go down,
+// instead it returns a RequeueAfterError as long as the VM is not down.
+func (ms *machineScope) stop(vm ovirtC.VM) error {
+	if vm.Status() == ovirtC.VMStatusDown {
+		return nil
+	}
+
+	if ms.isGracefulShutdown() {
+		stopped, err := ms.gracefulShutdown(vm)
+		if err != nil || !stopped {
+			return err
+		}
+	}
+
+	if err := vm.Stop(true, ovirtC.ContextStrategy(ms.Context)); err != nil {
+		return errors.Wrapf(err, "failed to stop VM %s", vm.ID())
+	}
+	ms.logger.Infof("powering off VM %s", vm.Name())
+	return &apierrors.RequeueAfterError{RequeueAfter: shutdownRequeueAfter}
+}
+
+// gracefulShutdown requests the guest to shut down and waits for the timeout of the shutdown policy.
+// It returns true once the timeout expired and the VM should be powered off.
+func (ms *machineScope) gracefulShutdown(vm ovirtC.VM) (bool, error) {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return false, errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+
+	if providerStatus.ShutdownStartedAt == nil {
+		if err := ms.ovirtClient.ShutdownVM(vm.ID(), false, ovirtC.ContextStrategy(ms.Context)); err != nil {
+			return false, errors.Wrapf(err, "failed to shut down VM %s", vm.ID())
+		}
+		ms.logger.Infof("shutting down VM %s", vm.Name())
+
+		// the start of the shutdown is recorded so the timeout survives the requeues
+		now := metav1.Now()
+		providerStatus.ShutdownStartedAt = &now
+		rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+		if err != nil {
+			return false, errors.Wrap(err, "error marshaling machine ProviderStatus field")
+		}
+		ms.machine.Status.ProviderStatus = rawExtension
+		if err := ms.patchMachine(ms.Context); err != nil {
+			return false, errors.Wrap(err, "failed to record the start of the shutdown")
+		}
+		return false, &apierrors.RequeueAfterError{RequeueAfter: shutdownRequeueAfter}
+	}
+
+	timeout := time.Duration(ms.shutdownTimeoutSeconds()) * time.Second
+	if elapsed := time.Since(providerStatus.ShutdownStartedAt.Time); elapsed < timeout {
+		ms.logger.Infof("waiting for VM %s to shut down, %s left before it is powered off",
+			vm.Name(), (timeout - elapsed).Round(time.Second))
+		return false, &apierrors.RequeueAfterError{RequeueAfter: shutdownRequeueAfter}
+	}
+	ms.logger.Infof("VM %s didn't shut down within %s", vm.Name(), timeout)
+	return true, nil
+}
+
+func (ms *machineScope) isGracefulShutdown() bool {
+	return ms.machineProviderSpec != nil && ms.machineProviderSpec.ShutdownPolicy != nil &&
+		ms.machineProviderSpec.ShutdownPolicy.Mode == shutdownModeGraceful
+}
+
+func (ms *machineScope) shutdownTimeoutSeconds() int32 {
+	if ms.machineProviderSpec.ShutdownPolicy.TimeoutSeconds > 0 {
+		return ms.machineProviderSpec.ShutdownPolicy.TimeoutSeconds
+	}
+	return defaultShutdownTimeoutSeconds
+}
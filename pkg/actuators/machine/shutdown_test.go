@@ -0,0 +1,116 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	apierrors "github.com/openshift/machine-api-operator/pkg/controller/machine"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestMachineScope_Stop(t *testing.T) {
+	testcases := []struct {
+		name              string
+		start             bool
+		shutdownPolicy    *v1beta1.ShutdownPolicy
+		shutdownStartedAt *v1.Time
+		expectRequeue     bool
+		expectPoweringOff bool
+	}{
+		{
+			name:  "VM which is down is not stopped",
+			start: false,
+		},
+		{
+			name:              "VM is powered off without shutdown policy",
+			start:             true,
+			expectRequeue:     true,
+			expectPoweringOff: true,
+		},
+		{
+			name:              "VM is not powered off during the graceful shutdown timeout",
+			start:             true,
+			shutdownPolicy:    &v1beta1.ShutdownPolicy{Mode: shutdownModeGraceful, TimeoutSeconds: 300},
+			shutdownStartedAt: &v1.Time{Time: time.Now().Add(-10 * time.Second)},
+			expectRequeue:     true,
+			expectPoweringOff: false,
+		},
+		{
+			name:              "VM is powered off after the graceful shutdown timeout",
+			start:             true,
+			shutdownPolicy:    &v1beta1.ShutdownPolicy{Mode: shutdownModeGraceful, TimeoutSeconds: 60},
+			shutdownStartedAt: &v1.Time{Time: time.Now().Add(-2 * time.Minute)},
+			expectRequeue:     true,
+			expectPoweringOff: true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+			if err != nil {
+				t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+			}
+			ovirtClient := helper.GetClient()
+			template, err := ovirtClient.GetBlankTemplate()
+			if err != nil {
+				t.Fatalf("Failed to get blank template: %v", err)
+			}
+			vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+			if err != nil {
+				t.Fatalf("Failed to create VM: %v", err)
+			}
+			if testcase.start {
+				if err := ovirtClient.StartVM(vm.ID()); err != nil {
+					t.Fatalf("Failed to start VM: %v", err)
+				}
+				if vm, err = ovirtClient.GetVM(vm.ID()); err != nil {
+					t.Fatalf("Failed to get VM: %v", err)
+				}
+			}
+
+			spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+			spec.ShutdownPolicy = testcase.shutdownPolicy
+			providerStatus, err := v1beta1.RawExtensionFromProviderStatus(
+				&v1beta1.OvirtMachineProviderStatus{ShutdownStartedAt: testcase.shutdownStartedAt})
+			if err != nil {
+				t.Fatalf("Failed to build provider status: %v", err)
+			}
+			ms := machineScope{
+				Context:             context.Background(),
+				logger:              ovirt.NewKLogr("test"),
+				ovirtClient:         ovirtClient,
+				machineProviderSpec: spec,
+				machine: &machinev1.Machine{
+					ObjectMeta: v1.ObjectMeta{Name: "test-machine"},
+					Status:     machinev1.MachineStatus{ProviderStatus: providerStatus},
+				},
+			}
+
+			err = ms.stop(vm)
+			var requeueAfterError *apierrors.RequeueAfterError
+			if isRequeue := errors.As(err, &requeueAfterError); isRequeue != testcase.expectRequeue {
+				t.Fatalf("Expected requeue to be %t, but got error %v", testcase.expectRequeue, err)
+			}
+			if !testcase.expectRequeue && err != nil {
+				t.Fatalf("Unexpected error occurred while stopping the VM: %v", err)
+			}
+
+			vm, err = ovirtClient.GetVM(vm.ID())
+			if err != nil {
+				t.Fatalf("Failed to get VM: %v", err)
+			}
+			if poweringOff := vm.Status() == ovirtclient.VMStatusPoweringDown; poweringOff != testcase.expectPoweringOff {
+				t.Errorf("Expected powering off to be %t, but VM status is %s", testcase.expectPoweringOff, vm.Status())
+			}
+		})
+	}
+}
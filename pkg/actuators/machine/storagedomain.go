@@ -0,0 +1,121 @@
+package machine
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	storageDomainPolicyMostFreeSpace = "most_free_space"
+	storageDomainPolicyRoundRobin    = "round_robin"
+	storageDomainPolicyFillFirst     = "fill_first"
+)
+
+// roundRobinStorageDomains keeps the next candidate index of every storage domain selector using the
+// round-robin policy, keyed by the candidates of the selector. The index is kept in memory only, so the rotation
+// restarts with the first candidate when the controller restarts, and it is shared by all machines, of any
+// cluster the controller serves, whose selectors list the same candidates.
+var roundRobinStorageDomains = struct {
+	sync.Mutex
+	next map[string]int
+}{next: map[string]int{}}
+
+// templateDisksStorageDomainID returns the storage domain the template disks are copied to, or an empty
+// ID if the disks stay on the storage domains of the template.
+func (ms *machineScope) templateDisksStorageDomainID(
+	diskAttachments []ovirtC.TemplateDiskAttachment) (ovirtC.StorageDomainID, error) {
+	if ms.machineProviderSpec.StorageDomainId != "" {
+		return ovirtC.StorageDomainID(ms.machineProviderSpec.StorageDomainId), nil
+	}
+	if ms.machineProviderSpec.StorageDomainSelector == nil {
+		return "", nil
+	}
+
+	var requiredBytes uint64
+	for _, diskAttachment := range diskAttachments {
+		disk, err := ms.ovirtClient.GetDisk(diskAttachment.DiskID(), ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return "", errors.Wrapf(err, "failed to fetch template disk %s", diskAttachment.DiskID())
+		}
+		diskBytes := disk.ProvisionedSize()
+		// the bootable disk is extended to the size of the OS disk after the VM is created
+		if osDiskBytes := uint64(ms.machineProviderSpec.OSDisk.SizeGB) * bytesInGB; diskAttachment.Bootable() &&
+			osDiskBytes > diskBytes {
+			diskBytes = osDiskBytes
+		}
+		requiredBytes += diskBytes
+	}
+	requiredBytes += ms.defaultStorageDomainAdditionalDisksBytes()
+	return ms.selectStorageDomain(requiredBytes)
+}
+
+// defaultStorageDomainAdditionalDisksBytes returns the size of the additional disks which are created on the
+// storage domain of the bootable disk, as they don't set a storage domain of their own.
+func (ms *machineScope) defaultStorageDomainAdditionalDisksBytes() uint64 {
+	var diskBytes uint64
+	for _, additionalDisk := range ms.machineProviderSpec.AdditionalDisks {
+		if additionalDisk.StorageDomainId == "" {
+			diskBytes += uint64(additionalDisk.SizeGB) * bytesInGB
+		}
+	}
+	return diskBytes
+}
+
+// selectStorageDomain selects a storage domain from the candidates of the storage domain selector by their
+// available space at the time of the call. Candidates with less than the required space are skipped.
+func (ms *machineScope) selectStorageDomain(requiredBytes uint64) (ovirtC.StorageDomainID, error) {
+	selector := ms.machineProviderSpec.StorageDomainSelector
+	storageDomains, err := ms.ovirtClient.ListStorageDomains(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to list storage domains")
+	}
+
+	candidates := make([]ovirtC.StorageDomain, 0, len(selector.StorageDomains))
+	for _, idOrName := range selector.StorageDomains {
+		for _, storageDomain := range storageDomains {
+			if string(storageDomain.ID()) != idOrName && storageDomain.Name() != idOrName {
+				continue
+			}
+			if storageDomain.Available() >= requiredBytes {
+				candidates = append(candidates, storageDomain)
+			}
+			break
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("none of the storage domains %v has %d GiB of free space for the VM disks",
+			selector.StorageDomains, requiredBytes/bytesInGB)
+	}
+
+	storageDomain := pickStorageDomain(candidates, selector.Policy, strings.Join(selector.StorageDomains, ","))
+	ms.logger.Infof("selected storage domain %s (%d GiB available) with policy %s",
+		storageDomain.Name(), storageDomain.Available()/bytesInGB, selector.Policy)
+	return storageDomain.ID(), nil
+}
+
+// pickStorageDomain picks one of the candidate storage domains according to the policy.
+// The key identifies the rotation of the round-robin policy.
+func pickStorageDomain(candidates []ovirtC.StorageDomain, policy string, key string) ovirtC.StorageDomain {
+	switch policy {
+	case storageDomainPolicyFillFirst:
+		return candidates[0]
+	case storageDomainPolicyRoundRobin:
+		roundRobinStorageDomains.Lock()
+		defer roundRobinStorageDomains.Unlock()
+		next := roundRobinStorageDomains.next[key] % len(candidates)
+		roundRobinStorageDomains.next[key] = next + 1
+		return candidates[next]
+	default:
+		selected := candidates[0]
+		for _, candidate := range candidates[1:] {
+			if candidate.Available() > selected.Available() {
+				selected = candidate
+			}
+		}
+		return selected
+	}
+}
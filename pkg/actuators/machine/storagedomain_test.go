@@ -0,0 +1,93 @@
+//go:build unit
+
+package machine
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+)
+
+type fakeStorageDomain struct {
+	id        ovirtC.StorageDomainID
+	available uint64
+}
+
+func (f fakeStorageDomain) ID() ovirtC.StorageDomainID {
+	return f.id
+}
+
+func (f fakeStorageDomain) Name() string {
+	return string(f.id)
+}
+
+func (f fakeStorageDomain) Available() uint64 {
+	return f.available
+}
+
+func (f fakeStorageDomain) StorageType() ovirtC.StorageDomainType {
+	return ovirtC.StorageDomainTypeNFS
+}
+
+func (f fakeStorageDomain) Status() ovirtC.StorageDomainStatus {
+	return ovirtC.StorageDomainStatusActive
+}
+
+func (f fakeStorageDomain) ExternalStatus() ovirtC.StorageDomainExternalStatus {
+	return ovirtC.StorageDomainExternalStatusOk
+}
+
+func TestPickStorageDomain(t *testing.T) {
+	candidates := []ovirtC.StorageDomain{
+		fakeStorageDomain{id: "data1", available: 100 * bytesInGB},
+		fakeStorageDomain{id: "data2", available: 300 * bytesInGB},
+		fakeStorageDomain{id: "data3", available: 200 * bytesInGB},
+	}
+
+	testcases := []struct {
+		name       string
+		policy     string
+		expectedID []ovirtC.StorageDomainID
+	}{
+		{
+			name:       "most free space is the default policy",
+			policy:     "",
+			expectedID: []ovirtC.StorageDomainID{"data2", "data2"},
+		},
+		{
+			name:       "fill first selects the first candidate",
+			policy:     storageDomainPolicyFillFirst,
+			expectedID: []ovirtC.StorageDomainID{"data1", "data1"},
+		},
+		{
+			name:       "round robin rotates through the candidates",
+			policy:     storageDomainPolicyRoundRobin,
+			expectedID: []ovirtC.StorageDomainID{"data1", "data2", "data3", "data1"},
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			for i, expectedID := range testcase.expectedID {
+				storageDomain := pickStorageDomain(candidates, testcase.policy, t.Name())
+				if storageDomain.ID() != expectedID {
+					t.Errorf("Expected selection %d to be storage domain %s, but got %s", i, expectedID, storageDomain.ID())
+				}
+			}
+		})
+	}
+}
+
+func TestMachineScope_DefaultStorageDomainAdditionalDisksBytes(t *testing.T) {
+	ms := machineScope{machineProviderSpec: &v1beta1.OvirtMachineProviderSpec{
+		AdditionalDisks: []*v1beta1.AdditionalDisk{
+			{SizeGB: 10},
+			{SizeGB: 20, StorageDomainId: "data2"},
+			{SizeGB: 30},
+		},
+	}}
+	if diskBytes := ms.defaultStorageDomainAdditionalDisksBytes(); diskBytes != 40*bytesInGB {
+		t.Errorf("Expected the additional disks to require %d bytes, but got %d", 40*bytesInGB, diskBytes)
+	}
+}
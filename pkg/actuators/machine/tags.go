@@ -0,0 +1,134 @@
+package machine
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+var invalidTagNameChars = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// desiredTags returns the names of the tags of the machine spec and of the mirrored machine labels, sorted
+// and without duplicates. Labels the machine doesn't have are skipped.
+func (ms *machineScope) desiredTags() []string {
+	names := map[string]bool{}
+	for _, tag := range ms.machineProviderSpec.Tags {
+		if tag != "" {
+			names[tag] = true
+		}
+	}
+	for _, key := range ms.machineProviderSpec.MirroredLabels {
+		if value, ok := ms.machine.Labels[key]; ok && value != "" {
+			names[labelTagName(key, value)] = true
+		}
+	}
+	tags := make([]string, 0, len(names))
+	for name := range names {
+		tags = append(tags, name)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// labelTagName returns the name of the tag a machine label is mirrored as.
+func labelTagName(key string, value string) string {
+	name := key[strings.LastIndex(key, "/")+1:]
+	return invalidTagNameChars.ReplaceAllString(name+"_"+value, "_")
+}
+
+// reconcileVMTags tags the VM with the tags of the machine spec and the mirrored labels, creating missing tags.
+// Tags which were added by the actuator before but aren't desired anymore are removed from the VM, tags added
+// to the VM by other means are kept. The tags of the VM are recorded in the provider status.
+func (ms *machineScope) reconcileVMTags(vm ovirtC.VM) error {
+	providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		return errors.Wrap(err, "error unmarshaling machine ProviderStatus field")
+	}
+	desired := ms.desiredTags()
+	if len(desired) == 0 && len(providerStatus.Tags) == 0 {
+		return nil
+	}
+
+	vmTags, err := ms.ovirtClient.ListVMTags(vm.ID(), ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list tags of VM %s", vm.ID())
+	}
+	vmTagIDs := make(map[string]ovirtC.TagID, len(vmTags))
+	for _, tag := range vmTags {
+		vmTagIDs[tag.Name()] = tag.ID()
+	}
+
+	desiredNames := make(map[string]bool, len(desired))
+	var missing []string
+	for _, name := range desired {
+		desiredNames[name] = true
+		if _, ok := vmTagIDs[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		tagIDs, err := ms.ensureTags(missing)
+		if err != nil {
+			return err
+		}
+		for _, name := range missing {
+			if err := ms.ovirtClient.AddTagToVM(vm.ID(), tagIDs[name], ovirtC.ContextStrategy(ms.Context)); err != nil {
+				return errors.Wrapf(err, "failed to tag VM %s with %s", vm.ID(), name)
+			}
+			ms.logger.Infof("tagged VM %s with %s", vm.Name(), name)
+		}
+	}
+
+	clusterTag := ms.machine.Labels[utils.ClusterIDLabel]
+	for _, name := range providerStatus.Tags {
+		tagID, ok := vmTagIDs[name]
+		if desiredNames[name] || !ok || name == clusterTag {
+			continue
+		}
+		if err := ms.ovirtClient.RemoveTagFromVM(vm.ID(), tagID, ovirtC.ContextStrategy(ms.Context)); err != nil {
+			return errors.Wrapf(err, "failed to remove tag %s from VM %s", name, vm.ID())
+		}
+		ms.logger.Infof("removed tag %s from VM %s", name, vm.Name())
+	}
+
+	providerStatus.Tags = desired
+	rawExtension, err := ovirtconfigv1.RawExtensionFromProviderStatus(providerStatus)
+	if err != nil {
+		return errors.Wrap(err, "error marshaling machine ProviderStatus field")
+	}
+	ms.machine.Status.ProviderStatus = rawExtension
+	return nil
+}
+
+// ensureTags returns the IDs of the tags with the given names, the tags which don't exist are created.
+func (ms *machineScope) ensureTags(names []string) (map[string]ovirtC.TagID, error) {
+	tags, err := ms.ovirtClient.ListTags(ovirtC.ContextStrategy(ms.Context))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to list tags")
+	}
+	tagIDs := make(map[string]ovirtC.TagID, len(names))
+	for _, tag := range tags {
+		tagIDs[tag.Name()] = tag.ID()
+	}
+	for _, name := range names {
+		if _, ok := tagIDs[name]; ok {
+			continue
+		}
+		tag, err := ms.ovirtClient.CreateTag(name, ovirtC.NewCreateTagParams().MustWithDescription(
+			fmt.Sprintf("Created by the oVirt machine actuator for machine %s", ms.machine.Name)),
+			ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			// another machine may have created the tag meanwhile, it is found by the next reconcile
+			return nil, errors.Wrapf(err, "failed to create tag %s", name)
+		}
+		ms.logger.Infof("created tag %s", name)
+		tagIDs[name] = tag.ID()
+	}
+	return tagIDs, nil
+}
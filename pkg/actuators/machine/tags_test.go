@@ -0,0 +1,116 @@
+//go:build unit
+
+package machine
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestLabelTagName(t *testing.T) {
+	testcases := []struct {
+		key      string
+		value    string
+		expected string
+	}{
+		{key: "machine.openshift.io/cluster-api-machine-role", value: "worker", expected: "cluster-api-machine-role_worker"},
+		{key: "machine.openshift.io/cluster-api-machineset", value: "ocp-x7k2-worker-0", expected: "cluster-api-machineset_ocp-x7k2-worker-0"},
+		{key: "team", value: "a b/c", expected: "team_a_b_c"},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.key, func(t *testing.T) {
+			if name := labelTagName(testcase.key, testcase.value); name != testcase.expected {
+				t.Errorf("Expected tag name %s, but got %s", testcase.expected, name)
+			}
+		})
+	}
+}
+
+func TestMachineScope_ReconcileVMTags(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), "test-machine", nil)
+	if err != nil {
+		t.Fatalf("Failed to create VM: %v", err)
+	}
+	clusterTag, err := ovirtClient.CreateTag("test-cluster", nil)
+	if err != nil {
+		t.Fatalf("Failed to create tag: %v", err)
+	}
+	if err := ovirtClient.AddTagToVM(vm.ID(), clusterTag.ID()); err != nil {
+		t.Fatalf("Failed to tag VM: %v", err)
+	}
+
+	spec := basicMachineProviderSpec(template.Name(), string(helper.GetClusterID()))
+	spec.Tags = []string{"team-a", "billing"}
+	spec.MirroredLabels = []string{"machine.openshift.io/cluster-api-machine-role", "missing"}
+	ms := machineScope{
+		Context:             context.Background(),
+		logger:              ovirt.NewKLogr("test"),
+		ovirtClient:         ovirtClient,
+		machineProviderSpec: spec,
+		machine: &machinev1.Machine{ObjectMeta: v1.ObjectMeta{
+			Name: "test-machine",
+			Labels: map[string]string{
+				utils.ClusterIDLabel:                            "test-cluster",
+				"machine.openshift.io/cluster-api-machine-role": "worker",
+			},
+		}},
+	}
+
+	if err := ms.reconcileVMTags(vm); err != nil {
+		t.Fatalf("Unexpected error occurred while reconciling the VM tags: %v", err)
+	}
+	expected := []string{"billing", "cluster-api-machine-role_worker", "team-a", "test-cluster"}
+	if tags := vmTagNames(t, ovirtClient, vm.ID()); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected VM tags %v, but got %v", expected, tags)
+	}
+
+	spec.Tags = []string{"team-b"}
+	if err := ms.reconcileVMTags(vm); err != nil {
+		t.Fatalf("Unexpected error occurred while reconciling the VM tags: %v", err)
+	}
+	expected = []string{"cluster-api-machine-role_worker", "team-b", "test-cluster"}
+	if tags := vmTagNames(t, ovirtClient, vm.ID()); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Expected VM tags %v, but got %v", expected, tags)
+	}
+
+	providerStatus, err := v1beta1.ProviderStatusFromRawExtension(ms.machine.Status.ProviderStatus)
+	if err != nil {
+		t.Fatalf("Failed to read provider status: %v", err)
+	}
+	expected = []string{"cluster-api-machine-role_worker", "team-b"}
+	if !reflect.DeepEqual(providerStatus.Tags, expected) {
+		t.Errorf("Expected status tags %v, but got %v", expected, providerStatus.Tags)
+	}
+}
+
+func vmTagNames(t *testing.T, ovirtClient ovirtclient.Client, id ovirtclient.VMID) []string {
+	tags, err := ovirtClient.ListVMTags(id)
+	if err != nil {
+		t.Fatalf("Failed to list VM tags: %v", err)
+	}
+	names := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		names = append(names, tag.Name())
+	}
+	sort.Strings(names)
+	return names
+}
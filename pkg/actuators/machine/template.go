@@ -0,0 +1,179 @@
+package machine
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+const templateVersionLatest = "latest"
+
+// resolveTemplateID returns the ID of the template the VM is created from. The template is
+// selected by the template name or ID and the template version of the machine spec.
+func (ms *machineScope) resolveTemplateID() (ovirtC.TemplateID, error) {
+	spec := ms.machineProviderSpec
+	if spec.TemplateVersion == "" {
+		if spec.TemplateId != "" {
+			template, err := ms.ovirtClient.GetTemplate(ovirtC.TemplateID(spec.TemplateId), ovirtC.ContextStrategy(ms.Context))
+			if err != nil {
+				return "", errors.Wrapf(err, "error finding template ID %s.", spec.TemplateId)
+			}
+			return template.ID(), nil
+		}
+		template, err := ms.ovirtClient.GetTemplateByName(spec.TemplateName, ovirtC.ContextStrategy(ms.Context))
+		if err != nil {
+			return "", errors.Wrapf(err, "error finding template name %s.", spec.TemplateName)
+		}
+		return template.ID(), nil
+	}
+
+	// go-ovirt-client doesn't expose template versions, so the oVirt SDK is used to list them
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return "", err
+	}
+	versions, err := listTemplateVersions(conn, spec.TemplateName, spec.TemplateId)
+	if err != nil {
+		return "", err
+	}
+	return selectTemplateVersion(versions, spec.TemplateVersion)
+}
+
+// listTemplateVersions returns all versions of the template given by its name or the ID of any of its versions.
+func listTemplateVersions(conn *ovirtsdk.Connection, templateName string, templateID string) ([]*ovirtsdk.Template, error) {
+	if templateID != "" {
+		response, err := conn.SystemService().TemplatesService().TemplateService(templateID).Get().Send()
+		if err != nil {
+			return nil, errors.Wrapf(err, "error finding template ID %s.", templateID)
+		}
+		template, ok := response.Template()
+		if !ok {
+			return nil, fmt.Errorf("template ID %s not found", templateID)
+		}
+		templateName, ok = template.Name()
+		if !ok {
+			return nil, fmt.Errorf("template ID %s has no name", templateID)
+		}
+	}
+
+	templatesByBase, err := listTemplatesByBaseTemplate(conn, templateName)
+	if err != nil {
+		return nil, err
+	}
+	if templateID != "" {
+		for _, versions := range templatesByBase {
+			for _, version := range versions {
+				if id, _ := version.Id(); id == templateID {
+					return versions, nil
+				}
+			}
+		}
+		return nil, fmt.Errorf("versions of template ID %s not found", templateID)
+	}
+
+	if len(templatesByBase) == 0 {
+		return nil, fmt.Errorf("template name %s not found", templateName)
+	}
+	if len(templatesByBase) > 1 {
+		return nil, ambiguousTemplateNameError(templateName, len(templatesByBase))
+	}
+	for _, versions := range templatesByBase {
+		return versions, nil
+	}
+	return nil, nil
+}
+
+// listTemplatesByBaseTemplate lists the templates with the given name grouped by the ID of their base template.
+// Template names are unique within a datacenter, so every group belongs to a different datacenter.
+func listTemplatesByBaseTemplate(conn *ovirtsdk.Connection, templateName string) (map[string][]*ovirtsdk.Template, error) {
+	search := "name=" + quoteSearchValue(templateName)
+	response, err := conn.SystemService().TemplatesService().List().Search(search).Send()
+	if err != nil {
+		return nil, errors.Wrapf(err, "error listing templates with name %s", templateName)
+	}
+	sdkTemplates, ok := response.Templates()
+	if !ok {
+		return nil, nil
+	}
+
+	templatesByBase := map[string][]*ovirtsdk.Template{}
+	for _, template := range sdkTemplates.Slice() {
+		if name, _ := template.Name(); name != templateName {
+			continue
+		}
+		baseID := baseTemplateID(template)
+		templatesByBase[baseID] = append(templatesByBase[baseID], template)
+	}
+	return templatesByBase, nil
+}
+
+// quoteSearchValue quotes a value of an engine search query, so spaces and search syntax in the value are
+// taken literally. The search still treats '*' as a wildcard, so the results have to be filtered by the value.
+func quoteSearchValue(value string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+// baseTemplateID returns the ID of the base template of a template version, which is the
+// ID of the template itself for the base version.
+func baseTemplateID(template *ovirtsdk.Template) string {
+	id, _ := template.Id()
+	version, ok := template.Version()
+	if !ok {
+		return id
+	}
+	baseTemplate, ok := version.BaseTemplate()
+	if !ok {
+		return id
+	}
+	if baseID, ok := baseTemplate.Id(); ok {
+		return baseID
+	}
+	return id
+}
+
+// selectTemplateVersion returns the ID of the template version matching the version selector,
+// which is either a version number or "latest".
+func selectTemplateVersion(versions []*ovirtsdk.Template, templateVersion string) (ovirtC.TemplateID, error) {
+	var selected *ovirtsdk.Template
+	var selectedNumber int64
+	for _, version := range versions {
+		number, ok := templateVersionNumber(version)
+		if !ok {
+			continue
+		}
+		if templateVersion == templateVersionLatest {
+			if selected == nil || number > selectedNumber {
+				selected, selectedNumber = version, number
+			}
+		} else if strconv.FormatInt(number, 10) == templateVersion {
+			selected = version
+			break
+		}
+	}
+	if selected == nil {
+		return "", fmt.Errorf("template version %s not found", templateVersion)
+	}
+	id, ok := selected.Id()
+	if !ok {
+		return "", fmt.Errorf("template version %s has no ID", templateVersion)
+	}
+	return ovirtC.TemplateID(id), nil
+}
+
+func templateVersionNumber(template *ovirtsdk.Template) (int64, bool) {
+	version, ok := template.Version()
+	if !ok {
+		return 0, false
+	}
+	return version.VersionNumber()
+}
+
+func ambiguousTemplateNameError(templateName string, count int) error {
+	return fmt.Errorf("template name %s matches %d templates in different datacenters, use template_id instead",
+		templateName, count)
+}
@@ -0,0 +1,93 @@
+//go:build unit
+
+package machine
+
+import (
+	"testing"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+)
+
+func TestSelectTemplateVersion(t *testing.T) {
+	versions := []*ovirtsdk.Template{
+		newTemplateVersion("base", "", 1),
+		newTemplateVersion("v3", "base", 3),
+		newTemplateVersion("v2", "base", 2),
+	}
+
+	testcases := []struct {
+		name            string
+		templateVersion string
+		expectedID      ovirtC.TemplateID
+		expectError     bool
+	}{
+		{
+			name:            "latest selects the highest version",
+			templateVersion: "latest",
+			expectedID:      "v3",
+		},
+		{
+			name:            "version number selects the matching version",
+			templateVersion: "2",
+			expectedID:      "v2",
+		},
+		{
+			name:            "version number of the base template selects the base template",
+			templateVersion: "1",
+			expectedID:      "base",
+		},
+		{
+			name:            "missing version fails",
+			templateVersion: "4",
+			expectError:     true,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			id, err := selectTemplateVersion(versions, testcase.templateVersion)
+			if (err != nil) != testcase.expectError {
+				t.Fatalf("Expected error (%t), but got '%v'", testcase.expectError, err)
+			}
+			if id != testcase.expectedID {
+				t.Errorf("Expected template ID %s, but got %s", testcase.expectedID, id)
+			}
+		})
+	}
+}
+
+func TestBaseTemplateID(t *testing.T) {
+	if id := baseTemplateID(newTemplateVersion("base", "", 1)); id != "base" {
+		t.Errorf("Expected base template to be its own base, but got %s", id)
+	}
+	if id := baseTemplateID(newTemplateVersion("v2", "base", 2)); id != "base" {
+		t.Errorf("Expected base template ID base, but got %s", id)
+	}
+}
+
+func newTemplateVersion(id string, baseID string, number int64) *ovirtsdk.Template {
+	versionBuilder := ovirtsdk.NewTemplateVersionBuilder().VersionNumber(number)
+	if baseID != "" {
+		versionBuilder.BaseTemplate(ovirtsdk.NewTemplateBuilder().Id(baseID).MustBuild())
+	}
+	return ovirtsdk.NewTemplateBuilder().
+		Id(id).
+		Name("rhcos").
+		Version(versionBuilder.MustBuild()).
+		MustBuild()
+}
+
+func TestQuoteSearchValue(t *testing.T) {
+	testcases := map[string]string{
+		"rhcos":             `"rhcos"`,
+		"rhcos 4.10":        `"rhcos 4.10"`,
+		`rhcos" or name="x`: `"rhcos\" or name=\"x"`,
+		`rhcos\`:            `"rhcos\\"`,
+	}
+	for value, expected := range testcases {
+		if quoted := quoteSearchValue(value); quoted != expected {
+			t.Errorf("Expected %s to be quoted as %s, but got %s", value, expected, quoted)
+		}
+	}
+}
@@ -2,8 +2,12 @@ package machine
 
 import (
 	"fmt"
+	"net"
+	"regexp"
+	"strconv"
 
 	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
 	ovirtC "github.com/ovirt/go-ovirt-client/v2"
 	"github.com/pkg/errors"
 )
@@ -15,6 +19,34 @@ const (
 	hugePages1GB              = 1048576
 )
 
+// engineError is a validation error caused by a failed request to the engine instead of by the machine spec.
+// The machine is requeued on an engineError instead of failing with InvalidMachineConfiguration.
+type engineError struct {
+	err error
+}
+
+func (e *engineError) Error() string {
+	return e.err.Error()
+}
+
+func (e *engineError) Unwrap() error {
+	return e.err
+}
+
+// newEngineError wraps the error of a request to the engine, nil stays nil.
+func newEngineError(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &engineError{err: errors.Wrap(err, message)}
+}
+
+// isEngineError returns true if the validation failed because of a failed request to the engine.
+func isEngineError(err error) bool {
+	var e *engineError
+	return errors.As(err, &e)
+}
+
 // validateMachine validates the machine object yaml fields and
 // returns InvalidMachineConfiguration in case the validation failed
 func validateMachine(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachineProviderSpec) error {
@@ -25,6 +57,10 @@ func validateMachine(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachi
 		return fmt.Errorf("%s UserDataSecret *Name* must be provided!", ErrorInvalidMachineObject)
 	}
 
+	if err := validateTemplate(ovirtClient, config); err != nil {
+		return errors.Wrap(err, "error validating Template")
+	}
+
 	err := validateInstanceID(config)
 	if err != nil {
 		return fmt.Errorf("error validating InstanceID %v", err)
@@ -45,7 +81,7 @@ func validateMachine(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachi
 	if config.AutoPinningPolicy != "" {
 		supported, err := ovirtClient.SupportsFeature(ovirtC.FeatureAutoPinning)
 		if err != nil {
-			return errors.Wrap(err, "failed to check autopinning support")
+			return newEngineError(err, "failed to check autopinning support")
 		}
 		if !supported {
 			return errors.Wrap(err, "autopinning is not supported.")
@@ -59,6 +95,107 @@ func validateMachine(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachi
 		return errors.Wrap(err, "error validating GuaranteedMemory")
 	}
 
+	if err := validateStorageDomainSelector(config); err != nil {
+		return errors.Wrap(err, "error validating StorageDomainSelector")
+	}
+
+	if err := validateAdditionalDisks(config.AdditionalDisks); err != nil {
+		return errors.Wrap(err, "error validating AdditionalDisks")
+	}
+
+	if err := validateNetworkInterfaces(config); err != nil {
+		return errors.Wrap(err, "error validating NetworkInterfaces")
+	}
+
+	if err := validatePlacement(config.Placement); err != nil {
+		return errors.Wrap(err, "error validating Placement")
+	}
+
+	if err := validateShutdownPolicy(config.ShutdownPolicy); err != nil {
+		return errors.Wrap(err, "error validating ShutdownPolicy")
+	}
+
+	if err := validateWatchdog(config.Watchdog); err != nil {
+		return errors.Wrap(err, "error validating Watchdog")
+	}
+
+	if err := validateConsoles(config); err != nil {
+		return errors.Wrap(err, "error validating Consoles")
+	}
+
+	if err := validateCreationFailurePolicy(config.CreationFailurePolicy); err != nil {
+		return errors.Wrap(err, "error validating CreationFailurePolicy")
+	}
+
+	if err := validateAffinityGroups(config); err != nil {
+		return errors.Wrap(err, "error validating AffinityGroups")
+	}
+
+	if err := validateSpreadPolicy(config.SpreadPolicy); err != nil {
+		return errors.Wrap(err, "error validating SpreadPolicy")
+	}
+
+	if err := validateTags(config); err != nil {
+		return errors.Wrap(err, "error validating Tags")
+	}
+
+	if err := validateNames(ovirtClient, config); err != nil {
+		return errors.Wrap(err, "error validating names")
+	}
+
+	if err := validateHighAvailability(ovirtClient, config); err != nil {
+		return errors.Wrap(err, "error validating HighAvailability")
+	}
+
+	return nil
+}
+
+// validateTemplate execute validations regarding the template selection.
+// A template name is rejected if it matches templates in several datacenters.
+// Returns: nil or error
+func validateTemplate(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	if config.TemplateName == "" && config.TemplateId == "" {
+		return fmt.Errorf("%s either TemplateName or TemplateId must be specified", ErrorInvalidMachineObject)
+	}
+	if config.TemplateName != "" && config.TemplateId != "" {
+		return fmt.Errorf("%s TemplateName and TemplateId cannot be set at the same time", ErrorInvalidMachineObject)
+	}
+	if config.TemplateVersion != "" && config.TemplateVersion != templateVersionLatest {
+		if version, err := strconv.ParseInt(config.TemplateVersion, 10, 64); err != nil || version <= 0 {
+			return fmt.Errorf("TemplateVersion must be a positive version number or %q, the value: %s is not valid",
+				templateVersionLatest, config.TemplateVersion)
+		}
+	}
+	if config.TemplateName == "" {
+		return nil
+	}
+
+	templates, err := ovirtClient.ListTemplates()
+	if err != nil {
+		return newEngineError(err, "failed to list templates")
+	}
+	matches := 0
+	for _, template := range templates {
+		if template.Name() == config.TemplateName {
+			matches++
+		}
+	}
+	if matches <= 1 {
+		return nil
+	}
+	// versions of a template share its name, they are told apart from templates
+	// in other datacenters by their base template with the oVirt SDK
+	conn, err := ovirt.GetSDKConnection(ovirtClient)
+	if err != nil {
+		return newEngineError(err, "failed to get SDK connection")
+	}
+	templatesByBase, err := listTemplatesByBaseTemplate(conn, config.TemplateName)
+	if err != nil {
+		return &engineError{err: err}
+	}
+	if len(templatesByBase) > 1 {
+		return ambiguousTemplateNameError(config.TemplateName, len(templatesByBase))
+	}
 	return nil
 }
 
@@ -66,7 +203,7 @@ func validateMachine(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachi
 // Returns: nil or InvalidMachineConfiguration
 func validateInstanceID(config *ovirtconfigv1.OvirtMachineProviderSpec) error {
 	// Cannot set InstanceTypeID and at same time: MemoryMB OR CPU
-	if len(config.InstanceTypeId) != 0 {
+	if len(config.InstanceTypeId) != 0 || len(config.InstanceTypeName) != 0 {
 		if config.MemoryMB != 0 || config.CPU != nil {
 			return fmt.Errorf(
 				"%s InstanceTypeID and MemoryMB OR CPU cannot be set at the same time", ErrorInvalidMachineObject)
@@ -127,3 +264,385 @@ func validateGuaranteedMemory(config *ovirtconfigv1.OvirtMachineProviderSpec) er
 	return nil
 
 }
+
+// validateStorageDomainSelector execute validation regarding the storage domain selection of the Virtual Machine disks
+// Returns: nil or error
+func validateStorageDomainSelector(config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	selector := config.StorageDomainSelector
+	if selector == nil {
+		return nil
+	}
+	if config.StorageDomainId != "" {
+		return fmt.Errorf("StorageDomainId and StorageDomainSelector cannot be set at the same time")
+	}
+	if len(selector.StorageDomains) == 0 {
+		return fmt.Errorf("at least one candidate storage domain must be specified")
+	}
+	switch selector.Policy {
+	case "", storageDomainPolicyMostFreeSpace, storageDomainPolicyRoundRobin, storageDomainPolicyFillFirst:
+		return nil
+	default:
+		return fmt.Errorf(
+			"the storage domain policy must be one of the following options: %s, %s, %s. The value: %s is not valid",
+			storageDomainPolicyMostFreeSpace, storageDomainPolicyRoundRobin, storageDomainPolicyFillFirst, selector.Policy)
+	}
+}
+
+// validateAdditionalDisks execute validation regarding the additional data disks of the Virtual Machine
+// Returns: nil or error
+func validateAdditionalDisks(disks []*ovirtconfigv1.AdditionalDisk) error {
+	for i, disk := range disks {
+		if disk == nil {
+			return fmt.Errorf("additional disk %d must not be empty", i)
+		}
+		if disk.SizeGB <= 0 {
+			return fmt.Errorf("additional disk %d *SizeGB* must be bigger than 0", i)
+		}
+		if disk.Format != "" {
+			if err := ovirtC.ImageFormat(disk.Format).Validate(); err != nil {
+				return fmt.Errorf("additional disk %d has an invalid format: %w", i, err)
+			}
+		}
+		if disk.Interface != "" {
+			if err := ovirtC.DiskInterface(disk.Interface).Validate(); err != nil {
+				return fmt.Errorf("additional disk %d has an invalid interface: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePlacement execute validation regarding the placement policy of the Virtual Machine
+// Returns: nil or error
+func validatePlacement(placement *ovirtconfigv1.Placement) error {
+	if placement == nil {
+		return nil
+	}
+	if len(placement.HostIDs) > 0 && placement.HostNameSelector != "" {
+		return fmt.Errorf("host IDs and host name selector cannot be set at the same time")
+	}
+	if placement.HostNameSelector != "" {
+		if _, err := regexp.Compile(placement.HostNameSelector); err != nil {
+			return fmt.Errorf("host name selector %s is not a valid regular expression: %w", placement.HostNameSelector, err)
+		}
+	}
+	if placement.Affinity != "" {
+		if err := ovirtC.VMAffinity(placement.Affinity).Validate(); err != nil {
+			return fmt.Errorf("invalid affinity: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateShutdownPolicy execute validation regarding the way the Virtual Machine is stopped on deletion
+// Returns: nil or error
+func validateShutdownPolicy(policy *ovirtconfigv1.ShutdownPolicy) error {
+	if policy == nil {
+		return nil
+	}
+	switch policy.Mode {
+	case "", shutdownModeForce, shutdownModeGraceful:
+	default:
+		return fmt.Errorf(
+			"the shutdown mode must be one of the following options: %s, %s. The value: %s is not valid",
+			shutdownModeForce, shutdownModeGraceful, policy.Mode)
+	}
+	if policy.TimeoutSeconds < 0 {
+		return fmt.Errorf("the shutdown timeout must not be negative, got %d", policy.TimeoutSeconds)
+	}
+	return nil
+}
+
+// validateHighAvailability execute validation regarding the high availability settings of the Virtual Machine.
+// The lease storage domain must be attached to the datacenter of the cluster of the Virtual Machine.
+// Returns: nil or error
+func validateHighAvailability(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	ha := config.HighAvailability
+	if ha == nil {
+		return nil
+	}
+	if ha.Priority < 0 || ha.Priority > 100 {
+		return fmt.Errorf("the high availability priority must be between 0 and 100, got %d", ha.Priority)
+	}
+	switch ha.ResumeBehaviour {
+	case "", resumeBehaviourAutoResume, resumeBehaviourLeavePaused, resumeBehaviourKill:
+	default:
+		return fmt.Errorf(
+			"the resume behaviour must be one of the following options: %s, %s, %s. The value: %s is not valid",
+			resumeBehaviourAutoResume, resumeBehaviourLeavePaused, resumeBehaviourKill, ha.ResumeBehaviour)
+	}
+	if ha.LeaseStorageDomainID == "" {
+		return nil
+	}
+	if ha.ResumeBehaviour != "" && ha.ResumeBehaviour != resumeBehaviourKill {
+		return fmt.Errorf("VMs with a lease only support the resume behaviour %s, got %s",
+			resumeBehaviourKill, ha.ResumeBehaviour)
+	}
+	clusterID := config.ClusterId
+	if clusterID == "" {
+		id, err := resolveClusterName(ovirtClient, config.ClusterName)
+		if err != nil {
+			return err
+		}
+		clusterID = string(id)
+	}
+	return leaseStorageDomainInDatacenter(ovirtClient, clusterID, ha.LeaseStorageDomainID)
+}
+
+// validateWatchdog execute validation regarding the watchdog device of the Virtual Machine
+// Returns: nil or error
+func validateWatchdog(watchdog *ovirtconfigv1.Watchdog) error {
+	if watchdog == nil {
+		return nil
+	}
+	switch watchdog.Model {
+	case "", watchdogModelI6300ESB, watchdogModelDiag288:
+	default:
+		return fmt.Errorf(
+			"the watchdog model must be one of the following options: %s, %s. The value: %s is not valid",
+			watchdogModelI6300ESB, watchdogModelDiag288, watchdog.Model)
+	}
+	switch watchdog.Action {
+	case "", watchdogActionReset, watchdogActionPowerOff, watchdogActionDump, watchdogActionPause:
+	default:
+		return fmt.Errorf(
+			"the watchdog action must be one of the following options: %s, %s, %s, %s. The value: %s is not valid",
+			watchdogActionReset, watchdogActionPowerOff, watchdogActionDump, watchdogActionPause, watchdog.Action)
+	}
+	return nil
+}
+
+// validateConsoles execute validation regarding the consoles and the peripheral devices of the Virtual Machine
+// Returns: nil or error
+func validateConsoles(config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	consoles := config.Consoles
+	if consoles == nil {
+		return nil
+	}
+	switch consoles.GraphicsProtocol {
+	case "", graphicsProtocolNone, graphicsProtocolVNC, graphicsProtocolSpice:
+	default:
+		return fmt.Errorf(
+			"the graphics protocol must be one of the following options: %s, %s, %s. The value: %s is not valid",
+			graphicsProtocolNone, graphicsProtocolVNC, graphicsProtocolSpice, consoles.GraphicsProtocol)
+	}
+	if consoles.Smartcard != nil && *consoles.Smartcard {
+		if protocol := graphicsProtocol(config); protocol == graphicsProtocolNone || protocol == graphicsProtocolVNC {
+			return fmt.Errorf("the smartcard requires a %s graphics console, the graphics protocol is %s",
+				graphicsProtocolSpice, protocol)
+		}
+	}
+	return nil
+}
+
+// validateCreationFailurePolicy execute validation regarding the handling of a failed Virtual Machine creation
+// Returns: nil or error
+func validateCreationFailurePolicy(policy string) error {
+	switch policy {
+	case "", creationFailurePolicyResume, creationFailurePolicyRecreate:
+		return nil
+	default:
+		return fmt.Errorf(
+			"the creation failure policy must be one of the following options: %s, %s. The value: %s is not valid",
+			creationFailurePolicyResume, creationFailurePolicyRecreate, policy)
+	}
+}
+
+// validateAffinityGroups execute validation regarding the declared affinity groups of the Virtual Machine
+// Returns: nil or error
+func validateAffinityGroups(config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	names := make(map[string]bool)
+	for _, name := range config.AffinityGroupsNames {
+		names[name] = true
+	}
+	for i, ag := range config.AffinityGroups {
+		if ag == nil {
+			return fmt.Errorf("affinity group %d must not be empty", i)
+		}
+		if ag.Name == "" {
+			return fmt.Errorf("affinity group %d *Name* must be specified", i)
+		}
+		if names[ag.Name] {
+			return fmt.Errorf("affinity group %s is used more than once", ag.Name)
+		}
+		names[ag.Name] = true
+		switch ag.Affinity {
+		case "", affinityPositive, affinityNegative:
+		default:
+			return fmt.Errorf(
+				"the affinity of affinity group %s must be one of the following options: %s, %s. The value: %s is not valid",
+				ag.Name, affinityPositive, affinityNegative, ag.Affinity)
+		}
+		switch ag.Rule {
+		case "", affinityRuleVMs, affinityRuleHosts:
+		default:
+			return fmt.Errorf(
+				"the rule of affinity group %s must be one of the following options: %s, %s. The value: %s is not valid",
+				ag.Name, affinityRuleVMs, affinityRuleHosts, ag.Rule)
+		}
+		if ag.Rule == affinityRuleHosts && len(ag.HostIDs) == 0 {
+			return fmt.Errorf("affinity group %s with the %s rule must specify the IDs of its hosts", ag.Name, ag.Rule)
+		}
+		if ag.Rule != affinityRuleHosts && len(ag.HostIDs) > 0 {
+			return fmt.Errorf("affinity group %s specifies hosts, which requires the %s rule", ag.Name, affinityRuleHosts)
+		}
+	}
+	return nil
+}
+
+// validateSpreadPolicy execute validation regarding the spreading of the Virtual Machines of a MachineSet
+// Returns: nil or error
+func validateSpreadPolicy(policy string) error {
+	switch policy {
+	case "", spreadPolicySoft, spreadPolicyHard:
+		return nil
+	default:
+		return fmt.Errorf(
+			"the spread policy must be one of the following options: %s, %s. The value: %s is not valid",
+			spreadPolicySoft, spreadPolicyHard, policy)
+	}
+}
+
+// validateTags execute validation regarding the tags of the Virtual Machine
+// Returns: nil or error
+func validateTags(config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	for _, tag := range config.Tags {
+		if tag == "" || invalidTagNameChars.MatchString(tag) {
+			return fmt.Errorf("tag %q must only contain letters, digits, \"_\", \"-\" and \".\"", tag)
+		}
+	}
+	for _, key := range config.MirroredLabels {
+		if key == "" {
+			return fmt.Errorf("mirrored label keys must not be empty")
+		}
+	}
+	return nil
+}
+
+// validateNames execute validations regarding the cluster, instance type and vNic profiles given by name.
+// A name must resolve to the ID given next to it.
+// Returns: nil or error
+func validateNames(ovirtClient ovirtC.Client, config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	if config.ClusterId == "" && config.ClusterName == "" {
+		return fmt.Errorf("%s either ClusterId or ClusterName must be specified", ErrorInvalidMachineObject)
+	}
+	for i, nic := range config.NetworkInterfaces {
+		if nic == nil {
+			continue
+		}
+		if (nic.Network == "") != (nic.VNICProfileName == "") {
+			return fmt.Errorf("network interface %d must specify both the network and the vNic profile name", i)
+		}
+		if nic.VNICProfileID == "" && nic.VNICProfileName == "" {
+			return fmt.Errorf("network interface %d must specify either the vNic profile ID or the network and "+
+				"the vNic profile name", i)
+		}
+	}
+	// the names are resolved on a copy, the actuator resolves them again for every reconcile
+	return resolveSpecNames(ovirtClient, config.DeepCopy())
+}
+
+// validNICName matches the network interface names which are safe to use as the file name of the
+// NetworkManager keyfile of the interface.
+var validNICName = regexp.MustCompile(`^[A-Za-z0-9_-][A-Za-z0-9_.-]{0,49}$`)
+
+// validateNetworkInterfaces execute validation regarding the network interfaces of the Virtual Machine
+// Returns: nil or error
+func validateNetworkInterfaces(config *ovirtconfigv1.OvirtMachineProviderSpec) error {
+	switch config.NetworkInterfacesMode {
+	case "", "replace", networkInterfacesModeAppend:
+	default:
+		return fmt.Errorf(
+			"the network interfaces mode must be one of the following options: replace, append. "+
+				"The value: %s is not valid", config.NetworkInterfacesMode)
+	}
+
+	names := make(map[string]bool)
+	for i, nic := range config.NetworkInterfaces {
+		if nic == nil {
+			return fmt.Errorf("network interface %d must not be empty", i)
+		}
+		if nic.Name != "" {
+			if !validNICName.MatchString(nic.Name) {
+				return fmt.Errorf("network interface name %q is not valid, it must have at most 50 letters, digits, "+
+					"'_', '-' or '.' and must not start with '.'", nic.Name)
+			}
+			if names[nic.Name] {
+				return fmt.Errorf("network interface name %s is used more than once", nic.Name)
+			}
+			names[nic.Name] = true
+		}
+		if nic.MACAddress != "" {
+			if _, err := net.ParseMAC(nic.MACAddress); err != nil {
+				return fmt.Errorf("network interface %d has an invalid MAC address: %w", i, err)
+			}
+		}
+		switch nic.InterfaceModel {
+		case "", "virtio", "e1000", "rtl8139", "rtl8139_virtio", "spapr_vlan", "pci_passthrough":
+		default:
+			return fmt.Errorf(
+				"network interface %d has an invalid interface model %s, it must be one of the following options: "+
+					"virtio, e1000, rtl8139, rtl8139_virtio, spapr_vlan, pci_passthrough", i, nic.InterfaceModel)
+		}
+		if nic.NetworkConfig != nil {
+			if err := validateNetworkConfig(nic); err != nil {
+				return fmt.Errorf("network interface %d has an invalid network configuration: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNetworkConfig execute validation regarding the static network configuration of a network interface
+// Returns: nil or error
+func validateNetworkConfig(nic *ovirtconfigv1.NetworkInterface) error {
+	networkConfig := nic.NetworkConfig
+	if nic.MACAddress == "" && networkConfig.GuestInterfaceName == "" {
+		return fmt.Errorf("either the MAC address or the guest interface name must be specified")
+	}
+	if networkConfig.IPPool != "" && (networkConfig.IPv4 != nil || networkConfig.IPv6 != nil) {
+		return fmt.Errorf("IP pool %s cannot be used together with a static IPv4 or IPv6 configuration", networkConfig.IPPool)
+	}
+	if err := validateIPConfig(networkConfig.IPv4, false); err != nil {
+		return errors.Wrap(err, "invalid IPv4 configuration")
+	}
+	if err := validateIPConfig(networkConfig.IPv6, true); err != nil {
+		return errors.Wrap(err, "invalid IPv6 configuration")
+	}
+	for _, server := range networkConfig.DNSServers {
+		if net.ParseIP(server) == nil {
+			return fmt.Errorf("DNS server %s is not a valid IP address", server)
+		}
+	}
+	return nil
+}
+
+// validateIPConfig execute validation regarding a static IP address configuration
+// Returns: nil or error
+func validateIPConfig(ipConfig *ovirtconfigv1.IPConfig, ipv6 bool) error {
+	if ipConfig == nil {
+		return nil
+	}
+	maxPrefix := int32(32)
+	if ipv6 {
+		maxPrefix = 128
+	}
+	if !isIPOfFamily(ipConfig.Address, ipv6) {
+		return fmt.Errorf("address %s is not valid", ipConfig.Address)
+	}
+	if ipConfig.Prefix <= 0 || ipConfig.Prefix > maxPrefix {
+		return fmt.Errorf("prefix %d must be between 1 and %d", ipConfig.Prefix, maxPrefix)
+	}
+	if ipConfig.Gateway != "" && !isIPOfFamily(ipConfig.Gateway, ipv6) {
+		return fmt.Errorf("gateway %s is not valid", ipConfig.Gateway)
+	}
+	return nil
+}
+
+func isIPOfFamily(address string, ipv6 bool) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	return (ip.To4() == nil) == ipv6
+}
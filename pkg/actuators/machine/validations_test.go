@@ -17,6 +17,10 @@ func TestValidateMachine(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to setup test helper: %v", err)
 	}
+	cluster, err := helper.GetClient().GetCluster(helper.GetClusterID())
+	if err != nil {
+		t.Fatalf("failed to get cluster: %v", err)
+	}
 
 	testCases := []struct {
 		name          string
@@ -128,6 +132,458 @@ func TestValidateMachine(t *testing.T) {
 			}),
 			expectIsValid: false,
 		},
+		{
+			name: "validation of machine provider spec with valid additional disk succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AdditionalDisks = []*v1beta1.AdditionalDisk{{SizeGB: 100, Format: "raw", Interface: "virtio"}}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with additional disk size 0 fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AdditionalDisks = []*v1beta1.AdditionalDisk{{SizeGB: 0}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid additional disk interface fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AdditionalDisks = []*v1beta1.AdditionalDisk{{SizeGB: 10, Interface: "floppy"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with valid network interface options succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfacesMode = "append"
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{
+					{Name: "eth-storage", VNICProfileID: "profile", MACAddress: "56:6f:1a:2b:00:01", InterfaceModel: "e1000"},
+				}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid MAC address fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{VNICProfileID: "profile", MACAddress: "56:6f"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with duplicate network interface names fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{
+					{Name: "nic1", VNICProfileID: "profile"},
+					{Name: "nic1", VNICProfileID: "profile"},
+				}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with a network interface name containing a slash fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{Name: "../../etc/passwd", VNICProfileID: "profile"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with a network interface name starting with a dot fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{Name: ".hidden", VNICProfileID: "profile"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with declared affinity groups succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AffinityGroups = []*v1beta1.AffinityGroup{
+					{Name: "workers", Affinity: "negative", Enforcing: true},
+					{Name: "storage", Affinity: "positive", Rule: "hosts", HostIDs: []string{"host"}},
+				}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with a hosts affinity rule without hosts fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AffinityGroups = []*v1beta1.AffinityGroup{{Name: "storage", Affinity: "positive", Rule: "hosts"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with hosts in a VMs affinity rule fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AffinityGroups = []*v1beta1.AffinityGroup{{Name: "workers", HostIDs: []string{"host"}}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with affinity group declared and named fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.AffinityGroupsNames = []string{"workers"}
+				omps.AffinityGroups = []*v1beta1.AffinityGroup{{Name: "workers"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with tags and mirrored labels succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Tags = []string{"team-a", "billing_2"}
+				omps.MirroredLabels = []string{"machine.openshift.io/cluster-api-machine-role"}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid tag name fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Tags = []string{"team a"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec without cluster fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.ClusterId = ""
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with conflicting cluster name and ID fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				// the basic spec uses another cluster ID
+				omps.ClusterName = cluster.Name()
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with network but without vNic profile name fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{Network: "ovirtmgmt"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid network interfaces mode fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfacesMode = "merge"
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with static network configuration succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{
+					VNICProfileID: "profile",
+					NetworkConfig: &v1beta1.NetworkConfig{
+						GuestInterfaceName: "ens3",
+						IPv4:               &v1beta1.IPConfig{Address: "10.0.0.10", Prefix: 24, Gateway: "10.0.0.1"},
+						IPv6:               &v1beta1.IPConfig{Address: "fd00::10", Prefix: 64},
+						DNSServers:         []string{"10.0.0.2"},
+					},
+				}}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with static network configuration without MAC and interface name fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{
+					VNICProfileID: "profile",
+					NetworkConfig: &v1beta1.NetworkConfig{
+						IPv4: &v1beta1.IPConfig{Address: "10.0.0.10", Prefix: 24},
+					},
+				}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with IPv6 address in IPv4 configuration fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{
+					VNICProfileID: "profile",
+					NetworkConfig: &v1beta1.NetworkConfig{
+						GuestInterfaceName: "ens3",
+						IPv4:               &v1beta1.IPConfig{Address: "fd00::10", Prefix: 24},
+					},
+				}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with IP pool succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{
+					VNICProfileID: "profile",
+					NetworkConfig: &v1beta1.NetworkConfig{
+						GuestInterfaceName: "ens3",
+						IPPool:             "workers",
+					},
+				}}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with IP pool and static IPv4 configuration fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.NetworkInterfaces = []*v1beta1.NetworkInterface{{
+					VNICProfileID: "profile",
+					NetworkConfig: &v1beta1.NetworkConfig{
+						GuestInterfaceName: "ens3",
+						IPPool:             "workers",
+						IPv4:               &v1beta1.IPConfig{Address: "10.0.0.10", Prefix: 24},
+					},
+				}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec without template fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.TemplateName = ""
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with template ID and latest template version succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.TemplateName = ""
+				omps.TemplateId = "0b4e5cfb-cd42-4e5f-a2c0-4d2a3c1a1c6f"
+				omps.TemplateVersion = "latest"
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with template name and template ID fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.TemplateId = "0b4e5cfb-cd42-4e5f-a2c0-4d2a3c1a1c6f"
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid template version fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.TemplateVersion = "newest"
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with placement on hosts succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Placement = &v1beta1.Placement{HostIDs: []string{"host-1", "host-2"}, Affinity: "pinned"}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with host IDs and host name selector fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Placement = &v1beta1.Placement{HostIDs: []string{"host-1"}, HostNameSelector: "^gpu-"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid host name selector fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Placement = &v1beta1.Placement{HostNameSelector: "gpu-(["}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid placement affinity fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Placement = &v1beta1.Placement{Affinity: "sticky"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with graceful shutdown policy succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.ShutdownPolicy = &v1beta1.ShutdownPolicy{Mode: "graceful", TimeoutSeconds: 120}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid shutdown mode fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.ShutdownPolicy = &v1beta1.ShutdownPolicy{Mode: "acpi"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with negative shutdown timeout fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.ShutdownPolicy = &v1beta1.ShutdownPolicy{Mode: "graceful", TimeoutSeconds: -1}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with high availability succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.HighAvailability = &v1beta1.HighAvailability{Enabled: true, Priority: 100, ResumeBehaviour: "leave_paused"}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid high availability priority fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.HighAvailability = &v1beta1.HighAvailability{Enabled: true, Priority: 101}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid resume behaviour fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.HighAvailability = &v1beta1.HighAvailability{Enabled: true, ResumeBehaviour: "resume"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with lease and resume behaviour other than kill fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.HighAvailability = &v1beta1.HighAvailability{
+					Enabled:              true,
+					LeaseStorageDomainID: "storage-domain",
+					ResumeBehaviour:      "auto_resume",
+				}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with watchdog succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Watchdog = &v1beta1.Watchdog{Model: "diag288", Action: "poweroff"}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid watchdog action fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Watchdog = &v1beta1.Watchdog{Action: "none"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with consoles succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				enabled := true
+				omps.Consoles = &v1beta1.Consoles{GraphicsProtocol: "vnc", SerialConsole: &enabled}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid graphics protocol fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.Consoles = &v1beta1.Consoles{GraphicsProtocol: "rdp"}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with smartcard on a high performance VM without graphics console fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				enabled := true
+				omps.VMType = "high_performance"
+				omps.Consoles = &v1beta1.Consoles{Smartcard: &enabled}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with recreate creation failure policy succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.CreationFailurePolicy = "recreate"
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with invalid creation failure policy fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.CreationFailurePolicy = "rollback"
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with storage domain selector succeeds",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.StorageDomainSelector = &v1beta1.StorageDomainSelector{
+					StorageDomains: []string{"data1", "data2"},
+					Policy:         "round_robin",
+				}
+				return omps
+			}),
+			expectIsValid: true,
+		},
+		{
+			name: "validation of machine provider spec with storage domain selector and storage domain ID fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.StorageDomainId = "data1"
+				omps.StorageDomainSelector = &v1beta1.StorageDomainSelector{StorageDomains: []string{"data2"}}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with storage domain selector without candidates fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.StorageDomainSelector = &v1beta1.StorageDomainSelector{}
+				return omps
+			}),
+			expectIsValid: false,
+		},
+		{
+			name: "validation of machine provider spec with invalid storage domain policy fails",
+			spec: BasicValidSpec(func(omps *v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMachineProviderSpec {
+				omps.StorageDomainSelector = &v1beta1.StorageDomainSelector{
+					StorageDomains: []string{"data1"},
+					Policy:         "random",
+				}
+				return omps
+			}),
+			expectIsValid: false,
+		},
 	}
 	for _, testcase := range testCases {
 		t.Run(testcase.name, func(t *testing.T) {
@@ -155,8 +611,9 @@ func BasicValidSpec(f func(*v1beta1.OvirtMachineProviderSpec) *v1beta1.OvirtMach
 			Threads: 1,
 			Sockets: 1,
 		},
-		Name:      "ovirt-vm-12345",
-		ClusterId: "46991e3f-8752-4ab6-9f2d-c37a98358d52",
+		Name:         "ovirt-vm-12345",
+		TemplateName: "rhcos-template",
+		ClusterId:    "46991e3f-8752-4ab6-9f2d-c37a98358d52",
 		UserDataSecret: &v1.LocalObjectReference{
 			Name: "top secret user data",
 		},
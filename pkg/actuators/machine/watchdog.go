@@ -0,0 +1,76 @@
+package machine
+
+import (
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+)
+
+const (
+	watchdogModelI6300ESB = "i6300esb"
+	watchdogModelDiag288  = "diag288"
+
+	watchdogActionReset    = "reset"
+	watchdogActionPowerOff = "poweroff"
+	watchdogActionDump     = "dump"
+	watchdogActionPause    = "pause"
+)
+
+// desiredWatchdog returns the watchdog of the spec with the defaults applied.
+func desiredWatchdog(watchdog *ovirtconfigv1.Watchdog) (ovirtsdk.WatchdogModel, ovirtsdk.WatchdogAction) {
+	model, action := ovirtsdk.WatchdogModel(watchdog.Model), ovirtsdk.WatchdogAction(watchdog.Action)
+	if model == "" {
+		model = ovirtsdk.WATCHDOGMODEL_I6300ESB
+	}
+	if action == "" {
+		action = ovirtsdk.WATCHDOGACTION_RESET
+	}
+	return model, action
+}
+
+// watchdogMatches returns true if the watchdog of the VM has the given model and action.
+func watchdogMatches(watchdog *ovirtsdk.Watchdog, model ovirtsdk.WatchdogModel, action ovirtsdk.WatchdogAction) bool {
+	currentModel, _ := watchdog.Model()
+	currentAction, _ := watchdog.Action()
+	return currentModel == model && currentAction == action
+}
+
+// reconcileWatchdog creates the watchdog of the spec on the VM, or updates the watchdog of the VM if its model
+// or action differ from the spec. A VM has at most one watchdog.
+// go-ovirt-client doesn't support watchdogs, so the oVirt SDK is used.
+func (ms *machineScope) reconcileWatchdog(id ovirtC.VMID) error {
+	if ms.machineProviderSpec.Watchdog == nil {
+		return nil
+	}
+	model, action := desiredWatchdog(ms.machineProviderSpec.Watchdog)
+	conn, err := ovirt.GetSDKConnection(ms.ovirtClient)
+	if err != nil {
+		return err
+	}
+	watchdogsService := conn.SystemService().VmsService().VmService(string(id)).WatchdogsService()
+	response, err := watchdogsService.List().Send()
+	if err != nil {
+		return errors.Wrapf(err, "failed to list watchdogs of VM %s", id)
+	}
+	watchdog := ovirtsdk.NewWatchdogBuilder().Model(model).Action(action).MustBuild()
+	if watchdogs, ok := response.Watchdogs(); ok && len(watchdogs.Slice()) > 0 {
+		current := watchdogs.Slice()[0]
+		if watchdogMatches(current, model, action) {
+			return nil
+		}
+		watchdogID, _ := current.Id()
+		ms.logger.Infof("updating watchdog of VM %s to model %s and action %s", id, model, action)
+		_, err := watchdogsService.WatchdogService(watchdogID).Update().Watchdog(watchdog).Send()
+		if err != nil {
+			return errors.Wrapf(err, "failed to update watchdog of VM %s", id)
+		}
+		return nil
+	}
+	ms.logger.Infof("creating watchdog with model %s and action %s on VM %s", model, action, id)
+	if _, err := watchdogsService.Add().Watchdog(watchdog).Send(); err != nil {
+		return errors.Wrapf(err, "failed to create watchdog on VM %s", id)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+//go:build unit
+
+package machine
+
+import (
+	"testing"
+
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	ovirtsdk "github.com/ovirt/go-ovirt"
+)
+
+func TestWatchdogMatches(t *testing.T) {
+	testcases := []struct {
+		name     string
+		current  *ovirtsdk.Watchdog
+		spec     *v1beta1.Watchdog
+		expected bool
+	}{
+		{
+			name: "defaults match a resetting i6300esb watchdog",
+			current: ovirtsdk.NewWatchdogBuilder().
+				Model(ovirtsdk.WATCHDOGMODEL_I6300ESB).Action(ovirtsdk.WATCHDOGACTION_RESET).MustBuild(),
+			spec:     &v1beta1.Watchdog{},
+			expected: true,
+		},
+		{
+			name: "other action doesn't match",
+			current: ovirtsdk.NewWatchdogBuilder().
+				Model(ovirtsdk.WATCHDOGMODEL_I6300ESB).Action(ovirtsdk.WATCHDOGACTION_NONE).MustBuild(),
+			spec:     &v1beta1.Watchdog{Action: "reset"},
+			expected: false,
+		},
+		{
+			name: "other model doesn't match",
+			current: ovirtsdk.NewWatchdogBuilder().
+				Model(ovirtsdk.WATCHDOGMODEL_I6300ESB).Action(ovirtsdk.WATCHDOGACTION_POWEROFF).MustBuild(),
+			spec:     &v1beta1.Watchdog{Model: "diag288", Action: "poweroff"},
+			expected: false,
+		},
+	}
+
+	for _, testcase := range testcases {
+		t.Run(testcase.name, func(t *testing.T) {
+			model, action := desiredWatchdog(testcase.spec)
+			if matches := watchdogMatches(testcase.current, model, action); matches != testcase.expected {
+				t.Errorf("Expected watchdog to match %t, but got %t", testcase.expected, matches)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+/*
+Copyright oVirt Authors
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+
+// OvirtIPPool is a pool of IP addresses that are allocated to the network interfaces of machines
+// on networks without DHCP.
+type OvirtIPPool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   OvirtIPPoolSpec   `json:"spec,omitempty"`
+	Status OvirtIPPoolStatus `json:"status,omitempty"`
+}
+
+// OvirtIPPoolSpec defines the addresses of the pool and the network configuration they are used with.
+type OvirtIPPoolSpec struct {
+	// Addresses is the list of addresses in the pool. Every entry is either a single address
+	// ("10.0.0.10"), a range of addresses ("10.0.0.10-10.0.0.50") or a CIDR ("10.0.0.0/28").
+	Addresses []string `json:"addresses"`
+
+	// Prefix is the length of the network prefix of the allocated addresses.
+	Prefix int32 `json:"prefix"`
+
+	// Gateway is the default gateway of the allocated addresses.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+
+	// DNSServers is the list of DNS servers used with the allocated addresses.
+	// +optional
+	DNSServers []string `json:"dnsServers,omitempty"`
+
+	// SearchDomains is the list of DNS search domains used with the allocated addresses.
+	// +optional
+	SearchDomains []string `json:"searchDomains,omitempty"`
+}
+
+// OvirtIPPoolStatus contains the addresses allocated from the pool.
+type OvirtIPPoolStatus struct {
+	// Allocations maps the allocated addresses to their owners in the form
+	// "<machine namespace>/<machine name>/<network interface name>".
+	// +optional
+	Allocations map[string]string `json:"allocations,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// OvirtIPPoolList contains a list of OvirtIPPool.
+type OvirtIPPoolList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []OvirtIPPool `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&OvirtIPPool{}, &OvirtIPPoolList{})
+}
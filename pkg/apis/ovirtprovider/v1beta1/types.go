@@ -44,23 +44,51 @@ type OvirtMachineProviderSpec struct {
 	Name string `json:"name"`
 
 	// The VM template this instance will be created from.
-	TemplateName string `json:"template_name"`
+	// Either TemplateName or TemplateId must be specified.
+	// +optional
+	TemplateName string `json:"template_name,omitempty"`
+
+	// TemplateId is the ID of the VM template this instance will be created from.
+	// Use it instead of TemplateName when templates with the same name exist in several datacenters.
+	// +optional
+	TemplateId string `json:"template_id,omitempty"`
+
+	// TemplateVersion selects a version of the template given by TemplateName or TemplateId.
+	// Either a version number (e.g. "3") or "latest" for the highest version of the template.
+	// If omitted the template given by TemplateName or TemplateId is used as it is.
+	// +optional
+	TemplateVersion string `json:"template_version,omitempty"`
 
 	// the oVirt cluster this VM instance belongs too.
-	ClusterId string `json:"cluster_id"`
+	// Either ClusterId or ClusterName must be specified.
+	// +optional
+	ClusterId string `json:"cluster_id,omitempty"`
+
+	// ClusterName is the name of the oVirt cluster this VM instance belongs to.
+	// It is resolved to the cluster ID, which is recorded in the provider status.
+	// +optional
+	ClusterName string `json:"cluster_name,omitempty"`
 
 	// InstanceTypeId defines the VM instance type and overrides
 	// the hardware parameters of the created VM, including cpu and memory.
 	// If InstanceTypeId is passed, all memory and cpu variables will be ignored.
 	InstanceTypeId string `json:"instance_type_id,omitempty"`
 
+	// InstanceTypeName is the name of the VM instance type, it can be used instead of InstanceTypeId.
+	// It is resolved to the instance type ID, which is recorded in the provider status.
+	// +optional
+	InstanceTypeName string `json:"instance_type_name,omitempty"`
+
 	// CPU defines the VM CPU.
+	// Additional sockets are hot-plugged into a running VM, other changes apply after a restart of the VM.
 	CPU *CPU `json:"cpu,omitempty"`
 
 	// MemoryMB is the size of a VM's memory in MiBs.
+	// Additional memory is hot-plugged into a running VM, a decrease applies after a restart of the VM.
 	MemoryMB int32 `json:"memory_mb,omitempty"`
 
 	// OSDisk is the the root disk of the node.
+	// The bootable disk of an existing VM is extended online when the size increases, it is never shrunk.
 	OSDisk *Disk `json:"os_disk,omitempty"`
 
 	// VMType defines the workload type the instance will
@@ -70,13 +98,45 @@ type OvirtMachineProviderSpec struct {
 
 	// NetworkInterfaces defines the list of the network interfaces of the VM.
 	// All network interfaces from the template are discarded and new ones will
-	// be created, unless the list is empty or nil
+	// be created, unless the list is empty or nil or NetworkInterfacesMode is "append".
 	NetworkInterfaces []*NetworkInterface `json:"network_interfaces,omitempty"`
 
+	// NetworkInterfacesMode defines how the NetworkInterfaces are applied to the network
+	// interfaces of the template.
+	// One of "replace, append". "replace" removes the network interfaces of the template,
+	// "append" keeps them and adds the NetworkInterfaces after them. Defaults to "replace".
+	// +kubebuilder:validation:Enum="";replace;append
+	// +optional
+	NetworkInterfacesMode string `json:"network_interfaces_mode,omitempty"`
+
 	// VMAffinityGroup contains the name of the OpenShift cluster affinity groups
 	// It will be used to add the newly created machine to the affinity groups
 	AffinityGroupsNames []string `json:"affinity_groups_names,omitempty"`
 
+	// AffinityGroups declares affinity groups the newly created machine is added to, together with their policy.
+	// Missing groups are created in the cluster of the VM with the declared policy.
+	// +optional
+	AffinityGroups []*AffinityGroup `json:"affinity_groups,omitempty"`
+
+	// SpreadPolicy places the VMs of the MachineSet owning the machine in a negative VM affinity group, so they
+	// run on different hosts. The group is created on first use and removed once it is empty.
+	// One of "soft, hard". "hard" only starts a VM on a host without another VM of the MachineSet.
+	// +kubebuilder:validation:Enum="";soft;hard
+	// +optional
+	SpreadPolicy string `json:"spread_policy,omitempty"`
+
+	// Tags are the names of oVirt tags the VM is tagged with in addition to the cluster tag.
+	// Missing tags are created.
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// MirroredLabels are the keys of Machine labels which are mirrored onto the VM as oVirt tags.
+	// A label is mirrored as a tag named "<name>_<value>", where name is the part of the key after the prefix,
+	// e.g. "cluster-api-machine-role_worker" for the label "machine.openshift.io/cluster-api-machine-role: worker".
+	// Characters which are not letters, digits, "_", "-" or "." are replaced with "_".
+	// +optional
+	MirroredLabels []string `json:"mirrored_labels,omitempty"`
+
 	// AutoPinningPolicy defines the policy to automatically set the CPU
 	// and NUMA including pinning to the host for the instance.
 	// One of "none, resize_and_pin"
@@ -116,6 +176,51 @@ type OvirtMachineProviderSpec struct {
 	//
 	// +optional
 	StorageDomainId string `json:"storage_domain_id,omitempty"`
+
+	// StorageDomainSelector selects the storage domain of the VM disks from a list of candidates
+	// when the VM is created. Cannot be set together with StorageDomainId.
+	//
+	// Note: this option supported only work when Clone is set to true (default)
+	//
+	// +optional
+	StorageDomainSelector *StorageDomainSelector `json:"storage_domain_selector,omitempty"`
+
+	// AdditionalDisks defines the list of data disks that are created and attached to the VM
+	// in addition to the disks of the template. The disks are attached before the VM is started.
+	// +optional
+	AdditionalDisks []*AdditionalDisk `json:"additional_disks,omitempty"`
+
+	// Placement defines the hosts the VM may run on and if it may be migrated between them.
+	// +optional
+	Placement *Placement `json:"placement,omitempty"`
+
+	// ShutdownPolicy defines how the VM is stopped before it is removed when the machine is deleted.
+	// Defaults to a forced power-off.
+	// +optional
+	ShutdownPolicy *ShutdownPolicy `json:"shutdown_policy,omitempty"`
+
+	// HighAvailability makes the engine restart the VM on another host when its host fails.
+	// It is applied once the VM is cloned, before the VM is started for the first time.
+	// +optional
+	HighAvailability *HighAvailability `json:"high_availability,omitempty"`
+
+	// Watchdog is the watchdog device of the VM, which lets the engine act on a guest whose kernel hangs.
+	// Watchdogs of the template are kept when no watchdog is set.
+	// +optional
+	Watchdog *Watchdog `json:"watchdog,omitempty"`
+
+	// Consoles defines the graphics and serial consoles and the sound, USB and smartcard devices of the VM.
+	// Settings which are not set keep the defaults of the VM type, high_performance VMs have no graphics
+	// console and no soundcard but a serial console, VMs of other types keep the devices of the template.
+	// +optional
+	Consoles *Consoles `json:"consoles,omitempty"`
+
+	// CreationFailurePolicy defines what happens to a VM when a step of its creation fails after it was cloned.
+	// One of "resume, recreate". "resume" retries the failed step, "recreate" removes the VM and creates it
+	// again, a VM is recreated up to 3 times before the failed step is resumed. Defaults to "resume".
+	// +kubebuilder:validation:Enum="";resume;recreate
+	// +optional
+	CreationFailurePolicy string `json:"creation_failure_policy,omitempty"`
 }
 
 // CPU defines the VM cpu, made of (Sockets * Cores * Threads)
@@ -138,10 +243,274 @@ type Disk struct {
 	SizeGB int64 `json:"size_gb"`
 }
 
+// AdditionalDisk defines a data disk that is created for the VM and attached as non-bootable.
+type AdditionalDisk struct {
+	// SizeGB size of the disk in GiB.
+	SizeGB int64 `json:"size_gb"`
+
+	// StorageDomainId is the ID of the storage domain the disk is created on.
+	// Defaults to the storage domain of the bootable disk.
+	// +optional
+	StorageDomainId string `json:"storage_domain_id,omitempty"`
+
+	// Format is the disk format. Can be "cow" or "raw".
+	// Defaults to the Format of the machine, or "cow" if that is not set either.
+	// +kubebuilder:validation:Enum="";raw;cow
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Sparse indicates whether the disk is thin provisioned.
+	// Defaults to the Sparse setting of the machine, or true if that is not set either.
+	// +optional
+	Sparse *bool `json:"sparse,omitempty"`
+
+	// Interface is the interface the disk is attached to the VM with.
+	// One of "virtio_scsi, virtio, sata, ide, spapr_vscsi". Defaults to "virtio_scsi".
+	// +kubebuilder:validation:Enum="";virtio_scsi;virtio;sata;ide;spapr_vscsi
+	// +optional
+	Interface string `json:"interface,omitempty"`
+}
+
 // NetworkInterface defines a VM network interface
 type NetworkInterface struct {
+	// Name is the name of the network interface on the VM.
+	// It consists of at most 50 letters, digits, '_', '-' or '.' and must not start with '.'.
+	// Defaults to "nicN", where N is the position of the interface on the VM.
+	// +optional
+	Name string `json:"name,omitempty"`
+
 	// VNICProfileID the id of the vNic profile
-	VNICProfileID string `json:"vnic_profile_id"`
+	// Either VNICProfileID or Network and VNICProfileName must be specified.
+	// +optional
+	VNICProfileID string `json:"vnic_profile_id,omitempty"`
+
+	// Network is the name of the logical network of the vNic profile given by VNICProfileName.
+	// +optional
+	Network string `json:"network,omitempty"`
+
+	// VNICProfileName is the name of the vNic profile in the network given by Network.
+	// It is resolved to the vNic profile ID, which is recorded in the provider status.
+	// +optional
+	VNICProfileName string `json:"vnic_profile_name,omitempty"`
+
+	// MACAddress is the MAC address of the network interface.
+	// If not set, the engine allocates an address from the MAC pool of the cluster.
+	// Selecting a MAC pool per network interface isn't supported, the engine assigns MAC pools to clusters
+	// only. Machines which need addresses of another MAC pool use a cluster with that MAC pool.
+	// +optional
+	MACAddress string `json:"mac_address,omitempty"`
+
+	// InterfaceModel is the model of the virtual network device.
+	// One of "virtio, e1000, rtl8139, rtl8139_virtio, spapr_vlan, pci_passthrough". Defaults to "virtio".
+	// +kubebuilder:validation:Enum="";virtio;e1000;rtl8139;rtl8139_virtio;spapr_vlan;pci_passthrough
+	// +optional
+	InterfaceModel string `json:"interface_model,omitempty"`
+
+	// Linked defines if the network cable of the interface is connected. Defaults to true.
+	// +optional
+	Linked *bool `json:"linked,omitempty"`
+
+	// Plugged defines if the interface is plugged into the VM. Defaults to true.
+	// +optional
+	Plugged *bool `json:"plugged,omitempty"`
+
+	// NetworkConfig defines the static network configuration of the interface in the guest.
+	// It is added to the ignition of the VM as a NetworkManager keyfile.
+	// +optional
+	NetworkConfig *NetworkConfig `json:"network_config,omitempty"`
+}
+
+// NetworkConfig defines the static network configuration of a network interface in the guest.
+// Address families that are not configured are left to DHCP and SLAAC.
+type NetworkConfig struct {
+	// GuestInterfaceName is the name of the interface in the guest, e.g. "ens3".
+	// The configuration is matched by the MACAddress of the network interface if set,
+	// so GuestInterfaceName is required only when MACAddress is not set.
+	// +optional
+	GuestInterfaceName string `json:"guest_interface_name,omitempty"`
+
+	// IPv4 is the static IPv4 configuration of the interface.
+	// +optional
+	IPv4 *IPConfig `json:"ipv4,omitempty"`
+
+	// IPv6 is the static IPv6 configuration of the interface.
+	// +optional
+	IPv6 *IPConfig `json:"ipv6,omitempty"`
+
+	// IPPool is the name of the OvirtIPPool the address of the interface is allocated from.
+	// The allocated address is used as IPv4 or IPv6 configuration depending on its address family,
+	// which must not be configured explicitly. The address is released when the machine is deleted.
+	// +optional
+	IPPool string `json:"ip_pool,omitempty"`
+
+	// DNSServers is the list of DNS servers of the interface.
+	// +optional
+	DNSServers []string `json:"dns_servers,omitempty"`
+
+	// SearchDomains is the list of DNS search domains of the interface.
+	// +optional
+	SearchDomains []string `json:"search_domains,omitempty"`
+}
+
+// IPConfig defines a static IP address configuration.
+type IPConfig struct {
+	// Address is the IP address of the interface.
+	Address string `json:"address"`
+
+	// Prefix is the length of the network prefix of the address.
+	Prefix int32 `json:"prefix"`
+
+	// Gateway is the default gateway of the address family.
+	// +optional
+	Gateway string `json:"gateway,omitempty"`
+}
+
+// StorageDomainSelector defines the candidate storage domains of the VM disks and how one of them is selected.
+type StorageDomainSelector struct {
+	// StorageDomains is the list of IDs or names of the candidate storage domains.
+	// Storage domains without enough free space for the disks of the VM are skipped, the disks of the VM
+	// include the additional disks without a storage domain of their own.
+	StorageDomains []string `json:"storage_domains"`
+
+	// Policy defines how the storage domain is selected from the candidates.
+	// One of "most_free_space, round_robin, fill_first". "most_free_space" selects the candidate
+	// with the most available space, "round_robin" rotates through the candidates and "fill_first"
+	// selects the first candidate in the list. Defaults to "most_free_space".
+	// The rotation of "round_robin" is kept in the memory of the controller, it restarts with the first
+	// candidate when the controller restarts and is shared by all selectors with the same candidates.
+	// +kubebuilder:validation:Enum="";most_free_space;round_robin;fill_first
+	// +optional
+	Policy string `json:"policy,omitempty"`
+}
+
+// Placement defines the placement policy of the VM.
+type Placement struct {
+	// HostIDs is the list of IDs of the hosts the VM may run on.
+	// Cannot be set together with HostNameSelector.
+	// +optional
+	HostIDs []string `json:"host_ids,omitempty"`
+
+	// HostNameSelector is a regular expression, the VM may run on the hosts of its cluster
+	// whose name matches it. Cannot be set together with HostIDs.
+	// +optional
+	HostNameSelector string `json:"host_name_selector,omitempty"`
+
+	// Affinity defines if the VM may be migrated to other hosts.
+	// One of "migratable, user_migratable, pinned". Defaults to "user_migratable" for
+	// the high_performance VM type and to "migratable" otherwise.
+	// +kubebuilder:validation:Enum="";migratable;user_migratable;pinned
+	// +optional
+	Affinity string `json:"affinity,omitempty"`
+}
+
+// AffinityGroup declares an affinity group of the oVirt cluster and its policy.
+type AffinityGroup struct {
+	// Name is the name of the affinity group.
+	Name string `json:"name"`
+
+	// Affinity is the direction of the rule.
+	// One of "positive, negative". "positive" keeps the VMs together, "negative" spreads them. Defaults to "negative".
+	// +kubebuilder:validation:Enum="";positive;negative
+	// +optional
+	Affinity string `json:"affinity,omitempty"`
+
+	// Enforcing defines if the VMs are only started when the rule is respected,
+	// otherwise the rule is applied on a best effort basis.
+	// +optional
+	Enforcing bool `json:"enforcing,omitempty"`
+
+	// Rule defines whether the rule applies between the VMs of the group or between the VMs and the hosts of the group.
+	// One of "vms, hosts". Defaults to "vms".
+	// +kubebuilder:validation:Enum="";vms;hosts
+	// +optional
+	Rule string `json:"rule,omitempty"`
+
+	// HostIDs is the list of IDs of the hosts of the group the hosts rule applies to.
+	// Required if Rule is "hosts" and not allowed otherwise. Hosts missing from the group are added to it.
+	// +optional
+	HostIDs []string `json:"host_ids,omitempty"`
+}
+
+// ShutdownPolicy defines how the VM is stopped when the machine is deleted.
+type ShutdownPolicy struct {
+	// Mode is the way the VM is stopped.
+	// One of "force, graceful". "force" powers the VM off immediately, "graceful" sends an ACPI
+	// shutdown to the guest and powers the VM off once the timeout expired. Defaults to "force".
+	// +kubebuilder:validation:Enum="";force;graceful
+	// +optional
+	Mode string `json:"mode,omitempty"`
+
+	// TimeoutSeconds is the time the guest has to shut down in the "graceful" mode before the VM
+	// is powered off. Defaults to 300.
+	// +optional
+	TimeoutSeconds int32 `json:"timeout_seconds,omitempty"`
+}
+
+// HighAvailability defines the high availability settings of the VM.
+type HighAvailability struct {
+	// Enabled makes the VM highly available.
+	Enabled bool `json:"enabled"`
+
+	// Priority is the order in which highly available VMs are restarted, VMs with a higher priority are
+	// restarted first. The engine uses 1 for low, 50 for medium and 100 for high priority.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// LeaseStorageDomainID is the ID of the storage domain holding the VM lease, which prevents the VM from
+	// running on two hosts when its host is not responsive. The storage domain must be in the datacenter
+	// of the cluster of the VM.
+	// +optional
+	LeaseStorageDomainID string `json:"lease_storage_domain_id,omitempty"`
+
+	// ResumeBehaviour defines what happens to the VM when it was paused because of a storage I/O error.
+	// One of "auto_resume, leave_paused, kill". VMs with a lease only support "kill".
+	// Defaults to the engine default.
+	// +kubebuilder:validation:Enum="";auto_resume;leave_paused;kill
+	// +optional
+	ResumeBehaviour string `json:"resume_behaviour,omitempty"`
+}
+
+// Watchdog defines the watchdog device of the VM.
+type Watchdog struct {
+	// Model is the emulated watchdog device.
+	// One of "i6300esb, diag288". "diag288" is the watchdog of s390x VMs. Defaults to "i6300esb".
+	// +kubebuilder:validation:Enum="";i6300esb;diag288
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// Action is what the engine does with the VM when the watchdog fires.
+	// One of "reset, poweroff, dump, pause". Defaults to "reset".
+	// +kubebuilder:validation:Enum="";reset;poweroff;dump;pause
+	// +optional
+	Action string `json:"action,omitempty"`
+}
+
+// Consoles defines the console and the peripheral devices of the VM.
+type Consoles struct {
+	// GraphicsProtocol is the protocol of the graphics console of the VM.
+	// One of "none, vnc, spice". "none" removes all graphics consoles, "vnc" and "spice" leave the VM
+	// with a single graphics console of the protocol.
+	// +kubebuilder:validation:Enum="";none;vnc;spice
+	// +optional
+	GraphicsProtocol string `json:"graphics_protocol,omitempty"`
+
+	// SerialConsole enables the serial console of the VM.
+	// +optional
+	SerialConsole *bool `json:"serial_console,omitempty"`
+
+	// Soundcard enables the soundcard of the VM.
+	// +optional
+	Soundcard *bool `json:"soundcard,omitempty"`
+
+	// USB enables the USB support of the VM.
+	// +optional
+	USB *bool `json:"usb,omitempty"`
+
+	// Smartcard enables the smartcard of the VM, which requires a SPICE graphics console.
+	// +optional
+	Smartcard *bool `json:"smartcard,omitempty"`
 }
 
 // +genclient
@@ -185,8 +554,102 @@ type OvirtMachineProviderStatus struct {
 	// InstanceState is the provisioning state of the oVirt Instance.
 	// +optional
 	InstanceState *string `json:"instanceState,omitempty"`
+
+	// CreationPhase is the phase of the VM creation the machine is in. The creation resumes from
+	// this phase on the next reconcile.
+	// One of "Cloning, ConfiguringDisks, ConfiguringNICs, Tagging, Starting, WaitingForIP, Created, Recreating".
+	// +optional
+	CreationPhase string `json:"creationPhase,omitempty"`
+
+	// Recreations is the number of times the VM was removed and created again after its creation failed
+	// +optional
+	Recreations int32 `json:"recreations,omitempty"`
+
+	// Conditions are the observations of the state of the VM.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+
+	// TemplateID is the ID of the template the VM was cloned from
+	// +optional
+	TemplateID string `json:"templateId,omitempty"`
+
+	// ClusterID is the ID of the oVirt cluster the VM is in
+	// +optional
+	ClusterID string `json:"clusterId,omitempty"`
+
+	// InstanceTypeID is the ID of the instance type resolved from the machine spec
+	// +optional
+	InstanceTypeID string `json:"instanceTypeId,omitempty"`
+
+	// VNICProfileIDs are the IDs of the vNic profiles resolved from the network interfaces of the machine spec,
+	// in the order of the network interfaces
+	// +optional
+	VNICProfileIDs []string `json:"vnicProfileIds,omitempty"`
+
+	// IPPools are the names of the IP pools addresses were allocated from for the network interfaces of the VM
+	// +optional
+	IPPools []string `json:"ipPools,omitempty"`
+
+	// HostID is the ID of the host the VM is currently running on
+	// +optional
+	HostID string `json:"hostId,omitempty"`
+
+	// AdditionalDiskIDs are the IDs of the disks created for the additional disks of the machine spec,
+	// in the order of the additional disks
+	// +optional
+	AdditionalDiskIDs []string `json:"additionalDiskIds,omitempty"`
+
+	// DiskIDs are the IDs of the disks attached to the VM
+	// +optional
+	DiskIDs []string `json:"diskIds,omitempty"`
+
+	// TemplateNICCount is the number of network interfaces the VM got from its template, the network interfaces
+	// of the spec are appended after them in the "append" network interfaces mode
+	// +optional
+	TemplateNICCount *int32 `json:"templateNicCount,omitempty"`
+
+	// NICIDs are the IDs of the network interfaces of the VM
+	// +optional
+	NICIDs []string `json:"nicIds,omitempty"`
+
+	// Tags are the tags of the machine spec and the mirrored labels the VM was tagged with
+	// +optional
+	Tags []string `json:"tags,omitempty"`
+
+	// OSDiskSizeGB is the OS disk size of the spec the bootable disk of the VM was last extended to
+	// +optional
+	OSDiskSizeGB int64 `json:"osDiskSizeGB,omitempty"`
+
+	// LastSuccessfulReconcile is the time the machine was last reconciled without an error
+	// +optional
+	LastSuccessfulReconcile *metav1.Time `json:"lastSuccessfulReconcile,omitempty"`
+
+	// ShutdownStartedAt is the time the graceful shutdown of the VM was requested when the machine was deleted
+	// +optional
+	ShutdownStartedAt *metav1.Time `json:"shutdownStartedAt,omitempty"`
 }
 
+// The condition types of the OvirtMachineProviderStatus.
+const (
+	// VMCreatedCondition is true once the VM is cloned from the template.
+	VMCreatedCondition = "VMCreated"
+	// DisksReadyCondition is true once the OS disk is extended and the additional disks are attached.
+	DisksReadyCondition = "DisksReady"
+	// NICsReadyCondition is true once the network interfaces of the VM are configured.
+	NICsReadyCondition = "NICsReady"
+	// VMRunningCondition is true while the VM is up.
+	VMRunningCondition = "VMRunning"
+	// AddressesReportedCondition is true once the guest agent reported a usable IP address.
+	AddressesReportedCondition = "AddressesReported"
+	// RestartRequiredCondition is true while CPU or memory changes of the spec only apply after a restart of the VM.
+	RestartRequiredCondition = "RestartRequired"
+	// OSDiskSizeAppliedCondition is true while the bootable disk of the VM has the OS disk size of the spec.
+	// It is false with the reason OSDiskShrinkRejected if the spec is smaller than the bootable disk.
+	OSDiskSizeAppliedCondition = "OSDiskSizeApplied"
+)
+
 func init() {
 	SchemeBuilder.Register(&OvirtMachineProviderSpec{})
 	SchemeBuilder.Register(&OvirtMachineProviderStatus{})
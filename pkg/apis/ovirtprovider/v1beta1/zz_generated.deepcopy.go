@@ -12,9 +12,50 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdditionalDisk) DeepCopyInto(out *AdditionalDisk) {
+	*out = *in
+	if in.Sparse != nil {
+		in, out := &in.Sparse, &out.Sparse
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdditionalDisk.
+func (in *AdditionalDisk) DeepCopy() *AdditionalDisk {
+	if in == nil {
+		return nil
+	}
+	out := new(AdditionalDisk)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AffinityGroup) DeepCopyInto(out *AffinityGroup) {
+	*out = *in
+	if in.HostIDs != nil {
+		in, out := &in.HostIDs, &out.HostIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AffinityGroup.
+func (in *AffinityGroup) DeepCopy() *AffinityGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(AffinityGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CPU) DeepCopyInto(out *CPU) {
 	*out = *in
@@ -30,6 +71,41 @@ func (in *CPU) DeepCopy() *CPU {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Consoles) DeepCopyInto(out *Consoles) {
+	*out = *in
+	if in.SerialConsole != nil {
+		in, out := &in.SerialConsole, &out.SerialConsole
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Soundcard != nil {
+		in, out := &in.Soundcard, &out.Soundcard
+		*out = new(bool)
+		**out = **in
+	}
+	if in.USB != nil {
+		in, out := &in.USB, &out.USB
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Smartcard != nil {
+		in, out := &in.Smartcard, &out.Smartcard
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Consoles.
+func (in *Consoles) DeepCopy() *Consoles {
+	if in == nil {
+		return nil
+	}
+	out := new(Consoles)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Disk) DeepCopyInto(out *Disk) {
 	*out = *in
@@ -45,9 +121,89 @@ func (in *Disk) DeepCopy() *Disk {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HighAvailability) DeepCopyInto(out *HighAvailability) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HighAvailability.
+func (in *HighAvailability) DeepCopy() *HighAvailability {
+	if in == nil {
+		return nil
+	}
+	out := new(HighAvailability)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IPConfig) DeepCopyInto(out *IPConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IPConfig.
+func (in *IPConfig) DeepCopy() *IPConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(IPConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkConfig) DeepCopyInto(out *NetworkConfig) {
+	*out = *in
+	if in.IPv4 != nil {
+		in, out := &in.IPv4, &out.IPv4
+		*out = new(IPConfig)
+		**out = **in
+	}
+	if in.IPv6 != nil {
+		in, out := &in.IPv6, &out.IPv6
+		*out = new(IPConfig)
+		**out = **in
+	}
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkConfig.
+func (in *NetworkConfig) DeepCopy() *NetworkConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NetworkInterface) DeepCopyInto(out *NetworkInterface) {
 	*out = *in
+	if in.Linked != nil {
+		in, out := &in.Linked, &out.Linked
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Plugged != nil {
+		in, out := &in.Plugged, &out.Plugged
+		*out = new(bool)
+		**out = **in
+	}
+	if in.NetworkConfig != nil {
+		in, out := &in.NetworkConfig, &out.NetworkConfig
+		*out = new(NetworkConfig)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkInterface.
@@ -120,6 +276,117 @@ func (in *OvirtClusterProviderStatus) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvirtIPPool) DeepCopyInto(out *OvirtIPPool) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvirtIPPool.
+func (in *OvirtIPPool) DeepCopy() *OvirtIPPool {
+	if in == nil {
+		return nil
+	}
+	out := new(OvirtIPPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvirtIPPool) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvirtIPPoolList) DeepCopyInto(out *OvirtIPPoolList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]OvirtIPPool, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvirtIPPoolList.
+func (in *OvirtIPPoolList) DeepCopy() *OvirtIPPoolList {
+	if in == nil {
+		return nil
+	}
+	out := new(OvirtIPPoolList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *OvirtIPPoolList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvirtIPPoolSpec) DeepCopyInto(out *OvirtIPPoolSpec) {
+	*out = *in
+	if in.Addresses != nil {
+		in, out := &in.Addresses, &out.Addresses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DNSServers != nil {
+		in, out := &in.DNSServers, &out.DNSServers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.SearchDomains != nil {
+		in, out := &in.SearchDomains, &out.SearchDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvirtIPPoolSpec.
+func (in *OvirtIPPoolSpec) DeepCopy() *OvirtIPPoolSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OvirtIPPoolSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OvirtIPPoolStatus) DeepCopyInto(out *OvirtIPPoolStatus) {
+	*out = *in
+	if in.Allocations != nil {
+		in, out := &in.Allocations, &out.Allocations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvirtIPPoolStatus.
+func (in *OvirtIPPoolStatus) DeepCopy() *OvirtIPPoolStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(OvirtIPPoolStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OvirtMachineProviderSpec) DeepCopyInto(out *OvirtMachineProviderSpec) {
 	*out = *in
@@ -152,7 +419,7 @@ func (in *OvirtMachineProviderSpec) DeepCopyInto(out *OvirtMachineProviderSpec)
 			if (*in)[i] != nil {
 				in, out := &(*in)[i], &(*out)[i]
 				*out = new(NetworkInterface)
-				**out = **in
+				(*in).DeepCopyInto(*out)
 			}
 		}
 	}
@@ -161,6 +428,27 @@ func (in *OvirtMachineProviderSpec) DeepCopyInto(out *OvirtMachineProviderSpec)
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AffinityGroups != nil {
+		in, out := &in.AffinityGroups, &out.AffinityGroups
+		*out = make([]*AffinityGroup, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(AffinityGroup)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.MirroredLabels != nil {
+		in, out := &in.MirroredLabels, &out.MirroredLabels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Clone != nil {
 		in, out := &in.Clone, &out.Clone
 		*out = new(bool)
@@ -171,6 +459,47 @@ func (in *OvirtMachineProviderSpec) DeepCopyInto(out *OvirtMachineProviderSpec)
 		*out = new(bool)
 		**out = **in
 	}
+	if in.StorageDomainSelector != nil {
+		in, out := &in.StorageDomainSelector, &out.StorageDomainSelector
+		*out = new(StorageDomainSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdditionalDisks != nil {
+		in, out := &in.AdditionalDisks, &out.AdditionalDisks
+		*out = make([]*AdditionalDisk, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(AdditionalDisk)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Placement != nil {
+		in, out := &in.Placement, &out.Placement
+		*out = new(Placement)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ShutdownPolicy != nil {
+		in, out := &in.ShutdownPolicy, &out.ShutdownPolicy
+		*out = new(ShutdownPolicy)
+		**out = **in
+	}
+	if in.HighAvailability != nil {
+		in, out := &in.HighAvailability, &out.HighAvailability
+		*out = new(HighAvailability)
+		**out = **in
+	}
+	if in.Watchdog != nil {
+		in, out := &in.Watchdog, &out.Watchdog
+		*out = new(Watchdog)
+		**out = **in
+	}
+	if in.Consoles != nil {
+		in, out := &in.Consoles, &out.Consoles
+		*out = new(Consoles)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvirtMachineProviderSpec.
@@ -206,6 +535,56 @@ func (in *OvirtMachineProviderStatus) DeepCopyInto(out *OvirtMachineProviderStat
 		*out = new(string)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VNICProfileIDs != nil {
+		in, out := &in.VNICProfileIDs, &out.VNICProfileIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPPools != nil {
+		in, out := &in.IPPools, &out.IPPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AdditionalDiskIDs != nil {
+		in, out := &in.AdditionalDiskIDs, &out.AdditionalDiskIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DiskIDs != nil {
+		in, out := &in.DiskIDs, &out.DiskIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.TemplateNICCount != nil {
+		in, out := &in.TemplateNICCount, &out.TemplateNICCount
+		*out = new(int32)
+		**out = **in
+	}
+	if in.NICIDs != nil {
+		in, out := &in.NICIDs, &out.NICIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tags != nil {
+		in, out := &in.Tags, &out.Tags
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSuccessfulReconcile != nil {
+		in, out := &in.LastSuccessfulReconcile, &out.LastSuccessfulReconcile
+		*out = (*in).DeepCopy()
+	}
+	if in.ShutdownStartedAt != nil {
+		in, out := &in.ShutdownStartedAt, &out.ShutdownStartedAt
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OvirtMachineProviderStatus.
@@ -225,3 +604,73 @@ func (in *OvirtMachineProviderStatus) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Placement) DeepCopyInto(out *Placement) {
+	*out = *in
+	if in.HostIDs != nil {
+		in, out := &in.HostIDs, &out.HostIDs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Placement.
+func (in *Placement) DeepCopy() *Placement {
+	if in == nil {
+		return nil
+	}
+	out := new(Placement)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ShutdownPolicy) DeepCopyInto(out *ShutdownPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ShutdownPolicy.
+func (in *ShutdownPolicy) DeepCopy() *ShutdownPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(ShutdownPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StorageDomainSelector) DeepCopyInto(out *StorageDomainSelector) {
+	*out = *in
+	if in.StorageDomains != nil {
+		in, out := &in.StorageDomains, &out.StorageDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StorageDomainSelector.
+func (in *StorageDomainSelector) DeepCopy() *StorageDomainSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(StorageDomainSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Watchdog) DeepCopyInto(out *Watchdog) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Watchdog.
+func (in *Watchdog) DeepCopy() *Watchdog {
+	if in == nil {
+		return nil
+	}
+	out := new(Watchdog)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -3,7 +3,6 @@ package controller
 import (
 	"context"
 	"fmt"
-	"strings"
 
 	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
 	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
@@ -70,7 +69,7 @@ func (r *nodeController) Reconcile(ctx context.Context, request reconcile.Reques
 		return ResultRequeueDefault(), errors.Wrap(err, "error getting node requeue")
 	}
 	// Check if the node has a ovirt ProviderID set, if not then ignore it
-	if !strings.Contains(node.Spec.ProviderID, utils.ProviderIDPrefix) {
+	if utils.VMIDFromProviderID(node.Spec.ProviderID) == "" {
 		return ResultNoRequeue(), nil
 	}
 	ovirtClient, err := r.GetoVirtClient()
@@ -80,7 +79,7 @@ func (r *nodeController) Reconcile(ctx context.Context, request reconcile.Reques
 		return ResultRequeueDefault(), errors.Wrap(err, msg)
 	}
 
-	vm, err := ovirtClient.GetVMByName(node.Name)
+	vm, err := ovirtClient.GetVM(ovirtC.VMID(utils.VMIDFromProviderID(node.Spec.ProviderID)))
 	if err != nil {
 		if ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
 			r.Log.Infof("Deleting Node %s from cluster since it has been removed from the oVirt engine", node.Name)
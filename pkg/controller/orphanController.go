@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	configv1 "github.com/openshift/api/config/v1"
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	ovirtconfigv1 "github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtC "github.com/ovirt/go-ovirt-client/v2"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+var _ manager.Runnable = &orphanController{}
+
+var (
+	orphanedVMs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "mapi_ovirt_orphaned_vms",
+		Help: "Number of VMs tagged with the infrastructure ID of the cluster which don't belong to any machine.",
+	})
+	deletedOrphanedVMs = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "mapi_ovirt_orphaned_vms_deleted_total",
+		Help: "Number of orphaned VMs removed after the grace period.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(orphanedVMs, deletedOrphanedVMs)
+}
+
+type orphanController struct {
+	baseController
+
+	eventRecorder record.EventRecorder
+	namespace     string
+	interval      time.Duration
+	// gracePeriod is the time a VM has to be orphaned before it is removed, orphans are only reported if it is 0
+	gracePeriod time.Duration
+	// orphanedSince keeps the time each orphaned VM was first detected. It is kept in memory only,
+	// so the grace period restarts when the controller restarts.
+	orphanedSince map[ovirtC.VMID]time.Time
+}
+
+// Creates a new Orphan Controller.
+func NewOrphanController(k8sClient client.Client, cachedOVirtClient ovirt.CachedOVirtClient,
+	eventRecorder record.EventRecorder, namespace string, interval time.Duration, gracePeriod time.Duration) *orphanController {
+	if namespace == "" {
+		namespace = utils.NAMESPACE
+	}
+	return &orphanController{
+		baseController: NewBaseController("OrphanController", k8sClient, cachedOVirtClient),
+		eventRecorder:  eventRecorder,
+		namespace:      namespace,
+		interval:       interval,
+		gracePeriod:    gracePeriod,
+		orphanedSince:  map[ovirtC.VMID]time.Time{},
+	}
+}
+
+// Adds the Orphan Controller to the manager.
+// The Orphan Controller periodically compares the VMs of the cluster with the machines, it runs on the leader only.
+func (ctrl *orphanController) AddToManager(mgr manager.Manager) error {
+	if err := mgr.Add(ctrl); err != nil {
+		return errors.Wrap(err, "error adding orphan controller")
+	}
+	return nil
+}
+
+// Start implements the controller runtime Runnable interface.
+func (ctrl *orphanController) Start(ctx context.Context) error {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := ctrl.collect(ctx); err != nil {
+			ctrl.Log.Errorf("failed to collect orphaned VMs: %v", err)
+		}
+	}, ctrl.interval)
+	return nil
+}
+
+// collect reports the orphaned VMs of the cluster and removes the ones orphaned for longer than the grace period.
+func (ctrl *orphanController) collect(ctx context.Context) error {
+	infra := &configv1.Infrastructure{}
+	if err := ctrl.Client.Get(ctx, client.ObjectKey{Name: "cluster"}, infra); err != nil {
+		return errors.Wrap(err, "error getting infrastructure data")
+	}
+	infraID := infra.Status.InfrastructureName
+	if infraID == "" {
+		return fmt.Errorf("infrastructure %s doesn't have an infrastructure ID", infra.Name)
+	}
+
+	ovirtClient, err := ctrl.GetoVirtClient()
+	if err != nil {
+		return errors.Wrap(err, "error getting connection to oVirt")
+	}
+	vms, err := ovirtClient.SearchVMs(ovirtC.VMSearchParams().WithTag(infraID), ovirtC.ContextStrategy(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "failed to list VMs tagged with %s", infraID)
+	}
+	machines := &machinev1.MachineList{}
+	if err := ctrl.Client.List(ctx, machines, client.InNamespace(ctrl.namespace)); err != nil {
+		return errors.Wrap(err, "failed to list machines")
+	}
+
+	orphans := findOrphans(vms, machines.Items)
+	orphanedVMs.Set(float64(len(orphans)))
+
+	now := time.Now()
+	orphanedSince := make(map[ovirtC.VMID]time.Time, len(orphans))
+	for _, vm := range orphans {
+		since, known := ctrl.orphanedSince[vm.ID()]
+		if !known {
+			since = now
+			ctrl.Log.Infof("VM %s(%s) doesn't belong to any machine", vm.Name(), vm.ID())
+			ctrl.eventRecorder.Eventf(infra, corev1.EventTypeWarning, "OrphanedVM",
+				"VM %s(%s) is tagged with %s but doesn't belong to any machine", vm.Name(), vm.ID(), infraID)
+		}
+		orphanedSince[vm.ID()] = since
+
+		if ctrl.gracePeriod <= 0 || now.Sub(since) < ctrl.gracePeriod {
+			continue
+		}
+		removed, err := ctrl.removeOrphan(ctx, ovirtClient, vm)
+		if err != nil {
+			ctrl.Log.Errorf("failed to remove orphaned VM %s(%s): %v", vm.Name(), vm.ID(), err)
+			continue
+		}
+		if removed {
+			deletedOrphanedVMs.Inc()
+			delete(orphanedSince, vm.ID())
+			ctrl.eventRecorder.Eventf(infra, corev1.EventTypeNormal, "OrphanedVMDeleted",
+				"Removed VM %s(%s) which didn't belong to any machine for %s", vm.Name(), vm.ID(), ctrl.gracePeriod)
+		}
+	}
+	ctrl.orphanedSince = orphanedSince
+	return nil
+}
+
+// removeOrphan powers the VM off and removes it once it is down. The disks of the oVirt CSI driver are detached
+// before, so the PersistentVolumes are kept. It returns true once the VM is removed.
+func (ctrl *orphanController) removeOrphan(ctx context.Context, ovirtClient ovirtC.Client, vm ovirtC.VM) (bool, error) {
+	if vm.Status() != ovirtC.VMStatusDown {
+		ctrl.Log.Infof("powering off orphaned VM %s(%s)", vm.Name(), vm.ID())
+		return false, ovirtClient.StopVM(vm.ID(), true, ovirtC.ContextStrategy(ctx))
+	}
+
+	diskAttachments, err := vm.ListDiskAttachments(ovirtC.ContextStrategy(ctx))
+	if err != nil {
+		return false, errors.Wrap(err, "failed to list disk attachments")
+	}
+	for _, diskAttachment := range diskAttachments {
+		disk, err := diskAttachment.Disk(ovirtC.ContextStrategy(ctx))
+		if err != nil {
+			return false, errors.Wrapf(err, "failed to fetch disk %s", diskAttachment.DiskID())
+		}
+		if !strings.HasPrefix(disk.Alias(), utils.CSIDiskAliasPrefix) {
+			continue
+		}
+		if err := diskAttachment.Remove(ovirtC.ContextStrategy(ctx)); err != nil {
+			return false, errors.Wrapf(err, "failed to detach disk %s", disk.Alias())
+		}
+		ctrl.Log.Infof("detached disk %s(%s) from orphaned VM %s to preserve it", disk.Alias(), disk.ID(), vm.Name())
+	}
+
+	ctrl.Log.Infof("removing orphaned VM %s(%s)", vm.Name(), vm.ID())
+	if err := vm.Remove(ovirtC.ContextStrategy(ctx)); err != nil && !ovirtC.HasErrorCode(err, ovirtC.ENotFound) {
+		return false, err
+	}
+	return true, nil
+}
+
+// findOrphans returns the VMs which don't belong to any of the machines. A VM belongs to a machine if its ID is
+// the provider ID, the VM ID annotation or the instance ID of the machine. A machine which didn't record the ID of
+// its VM yet keeps the VMs with its name, a VM with the name of a machine which recorded another VM ID, e.g. a
+// leftover of a recreated VM, is an orphan.
+func findOrphans(vms []ovirtC.VM, machines []machinev1.Machine) []ovirtC.VM {
+	machineVMIDs := map[ovirtC.VMID]bool{}
+	machineNames := map[string]bool{}
+	for _, machine := range machines {
+		var ids []ovirtC.VMID
+		if machine.Spec.ProviderID != nil {
+			if id := utils.VMIDFromProviderID(*machine.Spec.ProviderID); id != "" {
+				ids = append(ids, ovirtC.VMID(id))
+			}
+		}
+		if id, ok := machine.Annotations[utils.OvirtIDAnnotationKey]; ok && id != "" {
+			ids = append(ids, ovirtC.VMID(id))
+		}
+		providerStatus, err := ovirtconfigv1.ProviderStatusFromRawExtension(machine.Status.ProviderStatus)
+		if err != nil {
+			// the recorded ID is unknown, the VM with the name of the machine is kept
+			machineNames[machine.Name] = true
+		} else if providerStatus.InstanceID != nil {
+			ids = append(ids, ovirtC.VMID(*providerStatus.InstanceID))
+		}
+		if len(ids) == 0 {
+			machineNames[machine.Name] = true
+		}
+		for _, id := range ids {
+			machineVMIDs[id] = true
+		}
+	}
+
+	var orphans []ovirtC.VM
+	for _, vm := range vms {
+		if machineVMIDs[vm.ID()] || machineNames[vm.Name()] {
+			continue
+		}
+		orphans = append(orphans, vm)
+	}
+	return orphans
+}
@@ -0,0 +1,73 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	machinev1 "github.com/openshift/api/machine/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/apis/ovirtprovider/v1beta1"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/ovirt"
+	"github.com/openshift/cluster-api-provider-ovirt/pkg/utils"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFindOrphans(t *testing.T) {
+	helper, err := ovirtclient.NewMockTestHelper(ovirt.NewKLogr("go-ovirt-client"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred setting up test helper: %v", err)
+	}
+	ovirtClient := helper.GetClient()
+	template, err := ovirtClient.GetBlankTemplate()
+	if err != nil {
+		t.Fatalf("Failed to get blank template: %v", err)
+	}
+	vms := map[string]ovirtclient.VM{}
+	// machine-0 is a leftover VM with the name of a machine which recorded the ID of another VM
+	for _, name := range []string{"by-provider-id", "by-annotation", "by-instance-id", "by-name", "orphan", "machine-0"} {
+		vm, err := ovirtClient.CreateVM(helper.GetClusterID(), template.ID(), name, nil)
+		if err != nil {
+			t.Fatalf("Failed to create VM %s: %v", name, err)
+		}
+		vms[name] = vm
+	}
+
+	providerID := utils.ProviderIDPrefix + string(vms["by-provider-id"].ID())
+	instanceID := string(vms["by-instance-id"].ID())
+	providerStatus, err := v1beta1.RawExtensionFromProviderStatus(&v1beta1.OvirtMachineProviderStatus{InstanceID: &instanceID})
+	if err != nil {
+		t.Fatalf("Failed to build provider status: %v", err)
+	}
+	machines := []machinev1.Machine{
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "machine-0"},
+			Spec:       machinev1.MachineSpec{ProviderID: &providerID},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        "machine-1",
+				Annotations: map[string]string{utils.OvirtIDAnnotationKey: string(vms["by-annotation"].ID())},
+			},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "machine-2"},
+			Status:     machinev1.MachineStatus{ProviderStatus: providerStatus},
+		},
+		{
+			ObjectMeta: v1.ObjectMeta{Name: "by-name"},
+		},
+	}
+
+	orphans := findOrphans([]ovirtclient.VM{
+		vms["by-provider-id"], vms["by-annotation"], vms["by-instance-id"], vms["by-name"], vms["orphan"],
+		vms["machine-0"],
+	}, machines)
+	names := make([]string, 0, len(orphans))
+	for _, orphan := range orphans {
+		names = append(names, orphan.Name())
+	}
+	if len(names) != 2 || names[0] != "orphan" || names[1] != "machine-0" {
+		t.Errorf("Expected VMs orphan and machine-0 to be orphaned, but got %v", names)
+	}
+}
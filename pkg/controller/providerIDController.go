@@ -85,7 +85,8 @@ func (r *providerIDController) Reconcile(ctx context.Context, request reconcile.
 	return ResultNoRequeue(), nil
 }
 
-// fetchOvirtVmID returns the id of the oVirt VM which correlates to the node
+// fetchOvirtVmID returns the id of the oVirt VM which correlates to the node.
+// The node doesn't have a provider ID yet, so the VM can only be looked up by the name of the node.
 func (r *providerIDController) fetchOvirtVmID(nodeName string) (string, error) {
 	ovirtclient, err := r.GetoVirtClient()
 	if err != nil {
@@ -0,0 +1,18 @@
+package ovirt
+
+import (
+	"fmt"
+
+	ovirtsdk "github.com/ovirt/go-ovirt"
+	ovirtclient "github.com/ovirt/go-ovirt-client/v2"
+)
+
+// GetSDKConnection returns the underlying oVirt SDK connection of the given client.
+// It is used for the VM settings that are not covered by go-ovirt-client.
+func GetSDKConnection(client ovirtclient.Client) (*ovirtsdk.Connection, error) {
+	legacyClient, ok := client.(ovirtclient.ClientWithLegacySupport)
+	if !ok {
+		return nil, fmt.Errorf("oVirt client doesn't provide access to the underlying SDK connection")
+	}
+	return legacyClient.GetSDKClient(), nil
+}
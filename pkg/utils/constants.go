@@ -6,4 +6,10 @@ const (
 	OvirtCloudCredsSecretName = "ovirt-credentials"
 	NAMESPACE                 = "openshift-machine-api"
 	UserAgent                 = "cluster-api-provider-ovirt"
+	// ClusterIDLabel is the label of the machines with the infrastructure ID of the OpenShift cluster,
+	// the VMs of the cluster are tagged with its value.
+	ClusterIDLabel = "machine.openshift.io/cluster-api-cluster"
+	// CSIDiskAliasPrefix is the prefix of the alias of the disks created by the oVirt CSI driver,
+	// which names the disks after their PersistentVolume.
+	CSIDiskAliasPrefix = "pvc-"
 )
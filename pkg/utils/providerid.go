@@ -0,0 +1,12 @@
+package utils
+
+import "strings"
+
+// VMIDFromProviderID returns the ID of the oVirt VM of the provider ID, or an empty string if the provider ID
+// doesn't belong to an oVirt VM.
+func VMIDFromProviderID(providerID string) string {
+	if !strings.HasPrefix(providerID, ProviderIDPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(providerID, ProviderIDPrefix)
+}